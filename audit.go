@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/config"
+	"github.com/IdoKendo/mcparr/internal/tools"
+)
+
+// runAudit implements the "mcparr audit" subcommand, which prints the audit
+// log filtered by date, user and action.
+func runAudit(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: mcparr audit [flags]")
+		fmt.Fprintln(flags.Output(), "Print the actions that changed the library, newest first.")
+		flags.PrintDefaults()
+	}
+
+	path := flags.String("file", config.AuditLogPathFromEnv(), "audit log to read")
+	since := flags.String("since", "", "only show actions on or after this date, YYYY-MM-DD")
+	until := flags.String("until", "", "only show actions before this date, YYYY-MM-DD")
+	user := flags.String("user", "", "only show actions made by this MCP client")
+	action := flags.String("action", "", "only show actions of this kind: add, delete, search or edit")
+	limit := flags.Int("limit", 50, "maximum number of actions to show, 0 for all")
+	asJSON := flags.Bool("json", false, "print the matching entries as JSON lines")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	filter := audit.Filter{User: *user, Action: *action, Limit: *limit}
+	var err error
+	if filter.Since, err = tools.ParseDate(*since); err != nil {
+		fmt.Fprintf(out, "Invalid -since date: %v\n", err)
+		return exitError
+	}
+	if filter.Until, err = tools.ParseDate(*until); err != nil {
+		fmt.Fprintf(out, "Invalid -until date: %v\n", err)
+		return exitError
+	}
+
+	entries, err := audit.Read(*path, filter)
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		return exitError
+	}
+
+	encoder := json.NewEncoder(out)
+	for _, e := range entries {
+		if *asJSON {
+			encoder.Encode(e)
+		} else {
+			fmt.Fprintln(out, tools.FormatAuditEntry(e))
+		}
+	}
+
+	return exitOK
+}
@@ -0,0 +1,324 @@
+// Package approval keeps download requests from non-admin clients on hold
+// until an admin approves or denies them.
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Statuses of a request.
+const (
+	StatusPending = "pending"
+	// StatusApproving is a request an admin is approving: its media is being
+	// added, and it is approved or put back to pending once that is done.
+	StatusApproving = "approving"
+	StatusApproved  = "approved"
+	StatusDenied    = "denied"
+)
+
+// staleApproval is how long a request may be approving before it is taken
+// as pending again, in case the process approving it died.
+const staleApproval = 10 * time.Minute
+
+var (
+	// ErrNotFound is returned for a request ID that is not in the store.
+	ErrNotFound = errors.New("request not found")
+	// ErrDecided is returned when deciding a request that is no longer
+	// pending.
+	ErrDecided = errors.New("request was already decided")
+)
+
+// Request is a download request held for approval.
+type Request struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	MediaID   int       `json:"mediaId"`
+	Quality   string    `json:"quality,omitempty"`
+	Status    string    `json:"status"`
+	DecidedBy string    `json:"decidedBy,omitempty"`
+	DecidedAt time.Time `json:"decidedAt,omitzero"`
+	Reason    string    `json:"reason,omitempty"`
+	// Notified is set once the requester has been told of the decision.
+	Notified bool `json:"notified,omitempty"`
+}
+
+// pending reports whether r is waiting for a decision, including a request
+// whose approval was abandoned.
+func (r Request) pending(now time.Time) bool {
+	return r.Status == StatusPending || (r.Status == StatusApproving && now.Sub(r.DecidedAt) > staleApproval)
+}
+
+// Store is a queue of requests persisted as a JSON file, so pending requests
+// survive restarts. Every MCP client runs its own MCParr process over stdio,
+// so the file is shared between processes: each operation reloads it and
+// writes it back under an exclusive file lock.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open opens the store at path, checking that it can be read. The file is
+// created on the first request.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create approval queue directory: %w", err)
+	}
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add queues r as a pending request, assigning its ID and time.
+func (s *Store) Add(r Request) (Request, error) {
+	err := s.update(func(requests []Request) ([]Request, error) {
+		r.ID = 1
+		for _, existing := range requests {
+			r.ID = max(r.ID, existing.ID+1)
+		}
+		r.Status = StatusPending
+		if r.Time.IsZero() {
+			r.Time = time.Now()
+		}
+		return append(requests, r), nil
+	})
+	if err != nil {
+		return Request{}, err
+	}
+	return r, nil
+}
+
+// Pending returns the pending requests, oldest first.
+func (s *Store) Pending() ([]Request, error) {
+	var pending []Request
+	err := s.view(func(requests []Request) {
+		now := time.Now()
+		for _, r := range requests {
+			if r.pending(now) {
+				pending = append(pending, r)
+			}
+		}
+	})
+	return pending, err
+}
+
+// Get returns the request with the given ID.
+func (s *Store) Get(id int) (Request, error) {
+	var found Request
+	err := s.view(func(requests []Request) {
+		for _, r := range requests {
+			if r.ID == id {
+				found = r
+				return
+			}
+		}
+	})
+	if err != nil {
+		return Request{}, err
+	}
+	if found.ID == 0 {
+		return Request{}, ErrNotFound
+	}
+	return found, nil
+}
+
+// Requests returns the requests of user, oldest first.
+func (s *Store) Requests(user string) ([]Request, error) {
+	var found []Request
+	err := s.view(func(requests []Request) {
+		for _, r := range requests {
+			if r.User == user {
+				found = append(found, r)
+			}
+		}
+	})
+	return found, err
+}
+
+// Begin marks a pending request as being approved by decidedBy, so no one
+// else can approve or deny it meanwhile. It must be followed by Decide to
+// approve it, or Release to put it back.
+func (s *Store) Begin(id int, decidedBy string) (Request, error) {
+	return s.change(id, func(r *Request) error {
+		if !r.pending(time.Now()) {
+			return ErrDecided
+		}
+		r.Status = StatusApproving
+		r.DecidedBy = decidedBy
+		r.DecidedAt = time.Now()
+		return nil
+	})
+}
+
+// Release puts a request that is being approved back to pending.
+func (s *Store) Release(id int) (Request, error) {
+	return s.change(id, func(r *Request) error {
+		if r.Status != StatusApproving {
+			return ErrDecided
+		}
+		r.Status = StatusPending
+		r.DecidedBy = ""
+		r.DecidedAt = time.Time{}
+		return nil
+	})
+}
+
+// Decide sets the status of a request to approved or denied, recording who
+// decided and why. A pending request may be decided by anyone; one being
+// approved only by the admin who began approving it.
+func (s *Store) Decide(id int, status, decidedBy, reason string) (Request, error) {
+	return s.change(id, func(r *Request) error {
+		approving := r.Status == StatusApproving && r.DecidedBy == decidedBy
+		if !r.pending(time.Now()) && !approving {
+			return ErrDecided
+		}
+		r.Status = status
+		r.DecidedBy = decidedBy
+		r.DecidedAt = time.Now()
+		r.Reason = reason
+		return nil
+	})
+}
+
+// Updates returns the requests of user that were approved or denied since
+// the last call, oldest first, and marks them as notified.
+func (s *Store) Updates(user string) ([]Request, error) {
+	var updates []Request
+	err := s.update(func(requests []Request) ([]Request, error) {
+		for i, r := range requests {
+			if r.User != user || r.Notified || (r.Status != StatusApproved && r.Status != StatusDenied) {
+				continue
+			}
+			requests[i].Notified = true
+			updates = append(updates, requests[i])
+		}
+		return requests, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// change applies fn to the request with the given ID and saves it, unless fn
+// fails.
+func (s *Store) change(id int, fn func(r *Request) error) (Request, error) {
+	var changed Request
+	err := s.update(func(requests []Request) ([]Request, error) {
+		for i := range requests {
+			if requests[i].ID != id {
+				continue
+			}
+			if err := fn(&requests[i]); err != nil {
+				changed = requests[i]
+				return nil, err
+			}
+			changed = requests[i]
+			return requests, nil
+		}
+		return nil, ErrNotFound
+	})
+	return changed, err
+}
+
+// view calls fn with the requests in the file, read under the file lock.
+func (s *Store) view(fn func(requests []Request)) error {
+	return s.locked(func() error {
+		requests, err := s.load()
+		if err != nil {
+			return err
+		}
+		fn(requests)
+		return nil
+	})
+}
+
+// update replaces the requests in the file with those returned by fn, under
+// the file lock. Nothing is written if fn fails.
+func (s *Store) update(fn func(requests []Request) ([]Request, error)) error {
+	return s.locked(func() error {
+		requests, err := s.load()
+		if err != nil {
+			return err
+		}
+		requests, err = fn(requests)
+		if err != nil {
+			return err
+		}
+		return s.save(requests)
+	})
+}
+
+// locked calls fn holding the store's lock in this process and the lock
+// file shared with other processes.
+func (s *Store) locked(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open approval queue lock: %w", err)
+	}
+	defer file.Close()
+
+	if err := lockFile(file); err != nil {
+		return fmt.Errorf("failed to lock approval queue: %w", err)
+	}
+	defer unlockFile(file)
+
+	return fn()
+}
+
+// load reads the requests in the file. A missing file has none.
+func (s *Store) load() ([]Request, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval queue: %w", err)
+	}
+
+	var requests []Request
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return nil, fmt.Errorf("failed to parse approval queue: %w", err)
+	}
+	return requests, nil
+}
+
+// save writes requests to a temporary file and renames it over the old one,
+// so a crash never leaves a half-written queue.
+func (s *Store) save(requests []Request) error {
+	data, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval queue: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write approval queue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write approval queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write approval queue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write approval queue: %w", err)
+	}
+	return nil
+}
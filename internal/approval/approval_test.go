@@ -0,0 +1,116 @@
+package approval
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	first, err := store.Add(Request{User: "kids-tablet", Type: "movie", Title: "Arrival", MediaID: 329865})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store.Add(Request{User: "kids-tablet", Type: "series", Title: "Bluey", MediaID: 353546})
+
+	if first.ID != 1 || first.Status != StatusPending {
+		t.Errorf("Expected pending request 1, got %+v", first)
+	}
+
+	decided, err := store.Decide(first.ID, StatusApproved, "claude-desktop", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decided.Status != StatusApproved || decided.DecidedBy != "claude-desktop" {
+		t.Errorf("Expected the request to be approved by claude-desktop, got %+v", decided)
+	}
+
+	if _, err := store.Decide(first.ID, StatusDenied, "claude-desktop", ""); !errors.Is(err, ErrDecided) {
+		t.Errorf("Expected ErrDecided, got %v", err)
+	}
+	if _, err := store.Get(42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	pending, err := reopened.Pending()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pending) != 1 || pending[0].Title != "Bluey" {
+		t.Errorf("Expected Bluey to still be pending after reopening, got %+v", pending)
+	}
+
+	third, _ := reopened.Add(Request{User: "kids-tablet", Type: "movie", Title: "Dune"})
+	if third.ID != 3 {
+		t.Errorf("Expected the next ID to be 3, got %d", third.ID)
+	}
+}
+
+func TestStoreSharedBetweenProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.json")
+
+	// Each MCP client runs its own process, with its own store on the file.
+	requester, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	admin, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	first, _ := requester.Add(Request{User: "kids-tablet", Type: "movie", Title: "Arrival"})
+	second, _ := admin.Add(Request{User: "claude-desktop", Type: "movie", Title: "Dune"})
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("Expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+
+	pending, _ := admin.Pending()
+	if len(pending) != 2 {
+		t.Errorf("Expected the admin to see both requests, got %+v", pending)
+	}
+
+	if _, err := admin.Begin(first.ID, "claude-desktop"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := requester.Begin(first.ID, "other-admin"); !errors.Is(err, ErrDecided) {
+		t.Errorf("Expected a request being approved to be refused, got %v", err)
+	}
+	if _, err := requester.Decide(first.ID, StatusDenied, "other-admin", ""); !errors.Is(err, ErrDecided) {
+		t.Errorf("Expected a request being approved not to be denied, got %v", err)
+	}
+	if pending, _ := requester.Pending(); len(pending) != 1 {
+		t.Errorf("Expected a request being approved not to be pending, got %+v", pending)
+	}
+
+	if _, err := admin.Release(first.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	admin.Begin(first.ID, "claude-desktop")
+	if _, err := admin.Decide(first.ID, StatusApproved, "claude-desktop", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	updates, err := requester.Updates("kids-tablet")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(updates) != 1 || updates[0].Status != StatusApproved {
+		t.Errorf("Expected the approval as an update, got %+v", updates)
+	}
+	if updates, _ := admin.Updates("kids-tablet"); len(updates) != 0 {
+		t.Errorf("Expected updates to be given once, got %+v", updates)
+	}
+}
@@ -0,0 +1,359 @@
+// Package audit keeps an append-only JSONL trail of every action that
+// changes or searches the Sonarr or Radarr library.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/toolresult"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionAdd    = "add"
+	ActionDelete = "delete"
+	ActionSearch = "search"
+	ActionEdit   = "edit"
+	// ActionRead is recorded for denied calls to tools that don't change the
+	// library.
+	ActionRead = "read"
+)
+
+// Outcomes of an audited action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+	// OutcomePending is recorded for requests held for admin approval.
+	OutcomePending = "pending"
+)
+
+// Entry is a single audited action.
+type Entry struct {
+	Time      time.Time      `json:"time"`
+	User      string         `json:"user"`
+	Tool      string         `json:"tool"`
+	Action    string         `json:"action"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	Title     string         `json:"title,omitempty"`
+	MediaID   int            `json:"mediaId,omitempty"`
+	Outcome   string         `json:"outcome"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// Filter selects entries in Query. Zero fields match everything.
+type Filter struct {
+	Since  time.Time
+	Until  time.Time
+	User   string
+	Action string
+	// Limit is the maximum number of entries to return, newest first.
+	Limit int
+}
+
+// Matches reports whether e is selected by the filter, ignoring Limit.
+func (f Filter) Matches(e Entry) bool {
+	switch {
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	case f.User != "" && !strings.EqualFold(e.User, f.User):
+		return false
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	default:
+		return true
+	}
+}
+
+// Log is an append-only audit log file.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// Open opens the audit log at path for appending, creating it if needed.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &Log{path: path, file: file}, nil
+}
+
+// Record appends e to the log, setting its time if unset.
+func (l *Log) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching filter, newest first.
+func (l *Log) Query(filter Filter) ([]Entry, error) {
+	return Read(l.path, filter)
+}
+
+// Close flushes the log to disk and closes it.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to flush audit log: %w", err)
+	}
+	return l.file.Close()
+}
+
+// Read returns the entries of the audit log at path matching filter, newest
+// first. A missing file has no entries.
+func Read(path string, filter Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	return decode(file, filter)
+}
+
+func decode(r io.Reader, filter Filter) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip a line cut short by a crash rather than failing the query.
+			continue
+		}
+		if filter.Matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	slices.Reverse(entries)
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+// Recorder is implemented by Log.
+type Recorder interface {
+	Record(e Entry) error
+}
+
+type entryKey struct{}
+
+// Describe records the instance and the resolved title and ID of the media an
+// audited tool call acts on. It does nothing outside of an audited call.
+func Describe(ctx context.Context, instance, title string, mediaID int) {
+	if e, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		e.Instance = instance
+		e.Title = title
+		e.MediaID = mediaID
+	}
+}
+
+// Fail records the upstream error that made an audited tool call fail. It
+// does nothing outside of an audited call.
+func Fail(ctx context.Context, err error) {
+	if e, ok := ctx.Value(entryKey{}).(*Entry); ok && err != nil {
+		e.Error = err.Error()
+	}
+}
+
+// Deny records that an audited tool call was refused by policy, and makes
+// the call recorded even if its tool is not otherwise audited. It does
+// nothing outside of the audit middleware.
+func Deny(ctx context.Context, reason string) {
+	if e, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		e.Outcome = OutcomeDenied
+		e.Error = reason
+	}
+}
+
+// Hold records that an audited tool call was queued for approval instead of
+// being carried out. It does nothing outside of an audited call.
+func Hold(ctx context.Context) {
+	if e, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		e.Outcome = OutcomePending
+	}
+}
+
+type userKey struct{}
+
+// WithUser returns a context in which User is user. It is set from operator
+// config for each launch of the server, so the identity can be trusted,
+// unlike the name the client gives itself.
+func WithUser(ctx context.Context, user string) context.Context {
+	if user == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// User returns the identity of the MCP client making the request: the one
+// set with WithUser, or else the name the client gave when initializing,
+// which it may choose freely, or else "unknown".
+func User(ctx context.Context) string {
+	if user, ok := ctx.Value(userKey{}).(string); ok {
+		return user
+	}
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		if name := session.GetClientInfo().Name; name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// Middleware records every call to the tools in actions, which maps a tool
+// name to the action it performs, and every call denied by policy.
+func Middleware(recorder Recorder, actions map[string]string, logger *log.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			action, audited := actions[request.Params.Name]
+			if !audited {
+				action = ActionRead
+			}
+
+			entry := &Entry{
+				User:      User(ctx),
+				Tool:      request.Params.Name,
+				Action:    action,
+				Arguments: request.GetArguments(),
+			}
+			b := &batch{recorder: recorder, logger: logger}
+
+			ctx = context.WithValue(ctx, entryKey{}, entry)
+			result, err := next(context.WithValue(ctx, batchKey{}, b), request)
+
+			// Calls that recorded their items, or skipped recording, are
+			// not recorded again.
+			if entry.Outcome != OutcomeDenied && (!audited || b.itemized.Load() || b.skipped.Load()) {
+				return result, err
+			}
+
+			b.record(entry, result, err)
+			return result, err
+		}
+	}
+}
+
+type batchKey struct{}
+
+// batch records the entries of one tool call.
+type batch struct {
+	recorder Recorder
+	logger   *log.Logger
+	itemized atomic.Bool
+	skipped  atomic.Bool
+}
+
+// record sets the time and outcome of e from the result of the tool call and
+// appends it to the log.
+func (b *batch) record(e *Entry, result *mcp.CallToolResult, err error) {
+	e.Time = time.Now()
+	switch {
+	case e.Outcome == OutcomeDenied:
+	case e.Outcome == OutcomePending && err == nil:
+	case err != nil:
+		e.Outcome = OutcomeFailure
+		if e.Error == "" {
+			e.Error = err.Error()
+		}
+	case result != nil && result.IsError:
+		e.Outcome = OutcomeFailure
+		if e.Error == "" {
+			e.Error = toolresult.Text(result)
+		}
+	default:
+		e.Outcome = OutcomeSuccess
+	}
+
+	if err := b.recorder.Record(*e); err != nil {
+		b.logger.Printf("Error recording audit entry for %s: %v", e.Tool, err)
+	}
+}
+
+// Item starts auditing one item of a tool call that acts on many, such as a
+// batch add. Describe, Fail, Hold and Deny called with the returned context
+// apply to the item, and done records it as its own entry with the outcome
+// of result. An empty action means the action of the call. Once an item is
+// recorded, the call itself is not, unless it is denied. Outside of the audit
+// middleware, done does nothing.
+func Item(ctx context.Context, action string, arguments map[string]any) (context.Context, func(result *mcp.CallToolResult)) {
+	parent, ok := ctx.Value(entryKey{}).(*Entry)
+	b, batched := ctx.Value(batchKey{}).(*batch)
+	if !ok || !batched {
+		return ctx, func(*mcp.CallToolResult) {}
+	}
+
+	if action == "" {
+		action = parent.Action
+	}
+	item := &Entry{
+		User:      parent.User,
+		Tool:      parent.Tool,
+		Action:    action,
+		Arguments: arguments,
+	}
+	return context.WithValue(ctx, entryKey{}, item), func(result *mcp.CallToolResult) {
+		b.itemized.Store(true)
+		b.record(item, result, nil)
+	}
+}
+
+// Skip marks a call to an audited tool that turned out not to change the
+// library, such as one that only listed items, so it is not recorded. Items
+// recorded before or after still are.
+func Skip(ctx context.Context) {
+	if b, ok := ctx.Value(batchKey{}).(*batch); ok {
+		b.skipped.Store(true)
+	}
+}
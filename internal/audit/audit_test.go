@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLogQuery(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer l.Close()
+
+	day := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: day, User: "claude-desktop", Tool: "request_download", Action: ActionAdd, Title: "Arrival", Outcome: OutcomeSuccess},
+		{Time: day.Add(24 * time.Hour), User: "cursor", Tool: "request_delete", Action: ActionDelete, Title: "Dune", Outcome: OutcomeFailure},
+		{Time: day.Add(48 * time.Hour), User: "claude-desktop", Tool: "request_download", Action: ActionAdd, Title: "Severance", Outcome: OutcomeSuccess},
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all newest first", Filter{}, []string{"Severance", "Dune", "Arrival"}},
+		{"by user", Filter{User: "Claude-Desktop"}, []string{"Severance", "Arrival"}},
+		{"by action", Filter{Action: ActionDelete}, []string{"Dune"}},
+		{"by date", Filter{Since: day.Add(time.Hour), Until: day.Add(48 * time.Hour)}, []string{"Dune"}},
+		{"limit", Filter{Limit: 1}, []string{"Severance"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := l.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.want), len(got))
+			}
+			for i, title := range tt.want {
+				if got[i].Title != title {
+					t.Errorf("Expected entry %d to be '%s', got '%s'", i, title, got[i].Title)
+				}
+			}
+		})
+	}
+}
+
+type memoryRecorder struct {
+	entries []Entry
+}
+
+func (r *memoryRecorder) Record(e Entry) error {
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := &memoryRecorder{}
+	middleware := Middleware(recorder, map[string]string{"request_download": ActionAdd}, log.Default())
+
+	handler := middleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		Describe(ctx, "Radarr", "Arrival", 329865)
+		Fail(ctx, errors.New("Radarr rejected the request: This movie has already been added"))
+		return mcp.NewToolResultError("Failed to request download from Radarr"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "request_download"
+	request.Params.Arguments = map[string]any{"type": "movie", "id": 329865}
+	handler(context.Background(), request)
+
+	request.Params.Name = "search_media_id"
+	handler(context.Background(), request)
+
+	if len(recorder.entries) != 1 {
+		t.Fatalf("Expected only the mutating call to be recorded, got %d entries", len(recorder.entries))
+	}
+
+	e := recorder.entries[0]
+	if e.Action != ActionAdd || e.Tool != "request_download" || e.User != "unknown" {
+		t.Errorf("Expected an add by unknown via request_download, got %+v", e)
+	}
+	if e.Instance != "Radarr" || e.Title != "Arrival" || e.MediaID != 329865 {
+		t.Errorf("Expected the resolved media to be recorded, got %+v", e)
+	}
+	if e.Outcome != OutcomeFailure || e.Error != "Radarr rejected the request: This movie has already been added" {
+		t.Errorf("Expected the upstream error to be recorded, got %+v", e)
+	}
+	if e.Arguments["type"] != "movie" {
+		t.Errorf("Expected the arguments to be recorded, got %v", e.Arguments)
+	}
+}
+
+func TestMiddlewareItems(t *testing.T) {
+	recorder := &memoryRecorder{}
+	middleware := Middleware(recorder, map[string]string{"request_download_batch": ActionAdd}, log.Default())
+
+	handler := middleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		for _, title := range []string{"Arrival", "Dune"} {
+			itemCtx, done := Item(ctx, "", map[string]any{"name": title})
+			Describe(itemCtx, "Radarr", title, 0)
+			if title == "Dune" {
+				Hold(itemCtx)
+			}
+			done(mcp.NewToolResultText("ok"))
+		}
+		return mcp.NewToolResultText("Added 2 movies"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "request_download_batch"
+	handler(context.Background(), request)
+
+	if len(recorder.entries) != 2 {
+		t.Fatalf("Expected one entry per item, got %d entries", len(recorder.entries))
+	}
+	if e := recorder.entries[0]; e.Title != "Arrival" || e.Outcome != OutcomeSuccess || e.Tool != "request_download_batch" {
+		t.Errorf("Expected Arrival to be recorded as added, got %+v", e)
+	}
+	if e := recorder.entries[1]; e.Title != "Dune" || e.Outcome != OutcomePending {
+		t.Errorf("Expected Dune to be recorded as pending, got %+v", e)
+	}
+}
+
+func TestMiddlewareSkip(t *testing.T) {
+	recorder := &memoryRecorder{}
+	middleware := Middleware(recorder, map[string]string{"collection": ActionAdd}, log.Default())
+
+	handler := middleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		Skip(ctx)
+		if request.GetBool("monitor", false) {
+			_, done := Item(ctx, ActionEdit, map[string]any{"monitor": true})
+			done(mcp.NewToolResultText("ok"))
+		}
+		return mcp.NewToolResultText("Listed the collection"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "collection"
+	handler(context.Background(), request)
+	if len(recorder.entries) != 0 {
+		t.Fatalf("Expected a skipped call not to be recorded, got %+v", recorder.entries)
+	}
+
+	request.Params.Arguments = map[string]any{"monitor": true}
+	handler(context.Background(), request)
+	if len(recorder.entries) != 1 || recorder.entries[0].Action != ActionEdit {
+		t.Errorf("Expected the item to be recorded as an edit, got %+v", recorder.entries)
+	}
+}
+
+func TestUser(t *testing.T) {
+	if got := User(context.Background()); got != "unknown" {
+		t.Errorf("Expected 'unknown' without a client, got '%s'", got)
+	}
+	if got := User(WithUser(context.Background(), "kids-tablet")); got != "kids-tablet" {
+		t.Errorf("Expected the configured client 'kids-tablet', got '%s'", got)
+	}
+	if got := User(WithUser(context.Background(), "")); got != "unknown" {
+		t.Errorf("Expected an empty client ID to be ignored, got '%s'", got)
+	}
+}
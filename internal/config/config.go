@@ -4,8 +4,26 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// HTTPSettings holds how to connect to a Sonarr or Radarr instance that sits
+// behind HTTPS with a private CA, a forward proxy or an authenticating
+// reverse proxy.
+type HTTPSettings struct {
+	CAFile             string
+	ClientCertFile     string
+	ClientKeyFile      string
+	InsecureSkipVerify bool
+	ProxyURL           string
+	BasicAuthUser      string
+	BasicAuthPassword  string
+	Headers            map[string]string
+}
+
 // Config holds the application configuration including API endpoints and keys.
 type Config struct {
 	sonarrURL               string
@@ -15,6 +33,41 @@ type Config struct {
 	showsRootPath           string
 	moviesRootPath          string
 	defaultQualityProfileID int
+	webhookAddr             string
+	webhookSecret           string
+	metricsAddr             string
+	tracingExporter         string
+	shutdownTimeout         time.Duration
+	auditLogPath            string
+	readOnly                bool
+	allowTools              []string
+	denyTools               []string
+	policyFile              string
+	clientID                string
+	quotaMovies             int
+	quotaSeries             int
+	quotaPeriod             time.Duration
+	tagRequester            bool
+	movieTags               []string
+	seriesTags              []string
+	adminClients            []string
+	approvalQueuePath       string
+	minFreeSpaceGB          int
+	refuseLowSpace          bool
+	eventBufferSize         int
+	completionRefresh       time.Duration
+	retryMaxAttempts        int
+	retryBaseDelay          time.Duration
+	retryMaxDelay           time.Duration
+	breakerThreshold        int
+	breakerCooldown         time.Duration
+	cacheLookupTTL          time.Duration
+	cacheMetadataTTL        time.Duration
+	rateLimit               int
+	rateBurst               int
+	maxInFlight             int
+	sonarrHTTP              HTTPSettings
+	radarrHTTP              HTTPSettings
 }
 
 // New creates a new Config with values from environment variables.
@@ -26,6 +79,12 @@ func New() *Config {
 		log.Fatal("Missing SONARR_API_KEY and/or RADARR_API_KEY in env")
 	}
 
+	webhookAddr := os.Getenv("MCPARR_WEBHOOK_ADDR")
+	webhookSecret := os.Getenv("MCPARR_WEBHOOK_SECRET")
+	if webhookAddr != "" && webhookSecret == "" {
+		log.Fatal("MCPARR_WEBHOOK_SECRET is required when MCPARR_WEBHOOK_ADDR is set")
+	}
+
 	return &Config{
 		sonarrAPIKey:            sonarrApiKey,
 		radarrAPIKey:            radarrApiKey,
@@ -34,6 +93,41 @@ func New() *Config {
 		showsRootPath:           envWithDefault("SHOWS_ROOT_PATH", "/media/library/shows"),
 		moviesRootPath:          envWithDefault("MOVIES_ROOT_PATH", "/media/library/movies"),
 		defaultQualityProfileID: envIntWithDefault("DEFAULT_QUALITY_PROFILE_ID", 6),
+		webhookAddr:             webhookAddr,
+		webhookSecret:           webhookSecret,
+		metricsAddr:             os.Getenv("MCPARR_METRICS_ADDR"),
+		tracingExporter:         os.Getenv("MCPARR_TRACING_EXPORTER"),
+		shutdownTimeout:         envDurationWithDefault("MCPARR_SHUTDOWN_TIMEOUT", 30*time.Second),
+		auditLogPath:            AuditLogPathFromEnv(),
+		readOnly:                envBoolWithDefault("MCPARR_READ_ONLY", false),
+		allowTools:              splitList(os.Getenv("MCPARR_ALLOW_TOOLS")),
+		denyTools:               splitList(os.Getenv("MCPARR_DENY_TOOLS")),
+		policyFile:              os.Getenv("MCPARR_POLICY_FILE"),
+		clientID:                os.Getenv("MCPARR_CLIENT_ID"),
+		quotaMovies:             envIntWithDefault("MCPARR_QUOTA_MOVIES", 0),
+		quotaSeries:             envIntWithDefault("MCPARR_QUOTA_SERIES", 0),
+		quotaPeriod:             envDurationWithDefault("MCPARR_QUOTA_PERIOD", 7*24*time.Hour),
+		tagRequester:            envBoolWithDefault("MCPARR_TAG_REQUESTER", true),
+		movieTags:               splitList(os.Getenv("MCPARR_MOVIE_TAGS")),
+		seriesTags:              splitList(os.Getenv("MCPARR_SERIES_TAGS")),
+		adminClients:            splitList(os.Getenv("MCPARR_ADMIN_CLIENTS")),
+		approvalQueuePath:       envWithDefault("MCPARR_APPROVAL_QUEUE", cachePath("requests.json")),
+		minFreeSpaceGB:          envIntWithDefault("MCPARR_MIN_FREE_SPACE_GB", 0),
+		refuseLowSpace:          envBoolWithDefault("MCPARR_REFUSE_LOW_SPACE", false),
+		eventBufferSize:         envIntWithDefault("MCPARR_EVENT_BUFFER_SIZE", 100),
+		completionRefresh:       envDurationWithDefault("MCPARR_COMPLETION_REFRESH", 10*time.Minute),
+		retryMaxAttempts:        envIntWithDefault("MCPARR_RETRY_MAX_ATTEMPTS", 3),
+		retryBaseDelay:          envDurationWithDefault("MCPARR_RETRY_BASE_DELAY", 500*time.Millisecond),
+		retryMaxDelay:           envDurationWithDefault("MCPARR_RETRY_MAX_DELAY", 10*time.Second),
+		breakerThreshold:        envIntWithDefault("MCPARR_BREAKER_THRESHOLD", 5),
+		breakerCooldown:         envDurationWithDefault("MCPARR_BREAKER_COOLDOWN", 30*time.Second),
+		cacheLookupTTL:          envDurationWithDefault("MCPARR_CACHE_LOOKUP_TTL", 5*time.Minute),
+		cacheMetadataTTL:        envDurationWithDefault("MCPARR_CACHE_METADATA_TTL", time.Hour),
+		rateLimit:               envIntWithDefault("MCPARR_RATE_LIMIT", 10),
+		rateBurst:               envIntWithDefault("MCPARR_RATE_BURST", 20),
+		maxInFlight:             envIntWithDefault("MCPARR_MAX_IN_FLIGHT", 4),
+		sonarrHTTP:              httpSettings("SONARR"),
+		radarrHTTP:              httpSettings("RADARR"),
 	}
 }
 
@@ -72,6 +166,265 @@ func (c *Config) DefaultQualityProfileID() int {
 	return c.defaultQualityProfileID
 }
 
+// WebhookAddr returns the listen address of the webhook receiver, or an empty
+// string if the receiver is disabled.
+func (c *Config) WebhookAddr() string {
+	return c.webhookAddr
+}
+
+// WebhookSecret returns the shared secret webhooks must carry.
+func (c *Config) WebhookSecret() string {
+	return c.webhookSecret
+}
+
+// MetricsAddr returns the listen address of the Prometheus metrics endpoint,
+// or an empty string if it is disabled. It may be the webhook address, in
+// which case both share a listener.
+func (c *Config) MetricsAddr() string {
+	return c.metricsAddr
+}
+
+// TracingExporter returns the OpenTelemetry exporter to send traces to,
+// "otlphttp" or "otlpgrpc", or an empty string if tracing is disabled.
+func (c *Config) TracingExporter() string {
+	return c.tracingExporter
+}
+
+// ShutdownTimeout returns how long in-flight tool calls may run after a
+// shutdown signal before they are cancelled.
+func (c *Config) ShutdownTimeout() time.Duration {
+	return c.shutdownTimeout
+}
+
+// AuditLogPath returns the path of the audit log.
+func (c *Config) AuditLogPath() string {
+	return c.auditLogPath
+}
+
+// AuditLogPathFromEnv returns the audit log path from MCPARR_AUDIT_LOG, or
+// the default under the user's cache directory. Unlike New, it needs no API
+// keys, so commands that only read the audit log can use it.
+func AuditLogPathFromEnv() string {
+	if path := os.Getenv("MCPARR_AUDIT_LOG"); path != "" {
+		return path
+	}
+
+	return cachePath("audit.jsonl")
+}
+
+// cachePath returns the path of name under the user's cache directory, or
+// name itself if there is no home directory.
+func cachePath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(home, ".cache", "mcparr", name)
+}
+
+// ReadOnly reports whether tools that change the library are disabled.
+func (c *Config) ReadOnly() bool {
+	return c.readOnly
+}
+
+// AllowTools returns the only tools that may be called, or nil for all.
+func (c *Config) AllowTools() []string {
+	return c.allowTools
+}
+
+// DenyTools returns the tools that may not be called.
+func (c *Config) DenyTools() []string {
+	return c.denyTools
+}
+
+// PolicyFile returns the path of the per-client tool policy, or an empty
+// string if there is none.
+func (c *Config) PolicyFile() string {
+	return c.policyFile
+}
+
+// ClientID returns the identity of the MCP client this server was launched
+// for, or an empty string to use the name the client gives itself.
+func (c *Config) ClientID() string {
+	return c.clientID
+}
+
+// QuotaMovies returns how many movies each client may add per quota period,
+// or 0 for no limit.
+func (c *Config) QuotaMovies() int {
+	return c.quotaMovies
+}
+
+// QuotaSeries returns how many series each client may add per quota period,
+// or 0 for no limit.
+func (c *Config) QuotaSeries() int {
+	return c.quotaSeries
+}
+
+// QuotaPeriod returns the window over which quota usage is counted.
+func (c *Config) QuotaPeriod() time.Duration {
+	return c.quotaPeriod
+}
+
+// TagRequester reports whether added items are tagged with the MCP client
+// that requested them.
+func (c *Config) TagRequester() bool {
+	return c.tagRequester
+}
+
+// MovieTags returns extra tags applied to every movie mcparr adds.
+func (c *Config) MovieTags() []string {
+	return c.movieTags
+}
+
+// SeriesTags returns extra tags applied to every series mcparr adds.
+func (c *Config) SeriesTags() []string {
+	return c.seriesTags
+}
+
+// AdminClients returns the MCP clients whose download requests skip the
+// approval queue and who may approve or deny the others. If empty, there is
+// no approval queue.
+func (c *Config) AdminClients() []string {
+	return c.adminClients
+}
+
+// ApprovalQueuePath returns the path of the store of requests held for
+// approval.
+func (c *Config) ApprovalQueuePath() string {
+	return c.approvalQueuePath
+}
+
+// MinFreeSpaceGB returns the free space, in GB, a root folder should keep.
+// Adding to a root folder with less is warned about or refused. Zero
+// disables the check.
+func (c *Config) MinFreeSpaceGB() int {
+	return c.minFreeSpaceGB
+}
+
+// RefuseLowSpace reports whether adding to a root folder below the minimum
+// free space is refused rather than warned about.
+func (c *Config) RefuseLowSpace() bool {
+	return c.refuseLowSpace
+}
+
+// EventBufferSize returns the number of recent events to keep.
+func (c *Config) EventBufferSize() int {
+	return c.eventBufferSize
+}
+
+// CompletionRefresh returns how long the argument completion index is kept
+// before being refreshed from Sonarr and Radarr.
+func (c *Config) CompletionRefresh() time.Duration {
+	return c.completionRefresh
+}
+
+// RetryMaxAttempts returns how many times a failed request is attempted in
+// total, including the first attempt.
+func (c *Config) RetryMaxAttempts() int {
+	return c.retryMaxAttempts
+}
+
+// RetryBaseDelay returns the delay before the first retry.
+func (c *Config) RetryBaseDelay() time.Duration {
+	return c.retryBaseDelay
+}
+
+// RetryMaxDelay returns the longest delay between retries.
+func (c *Config) RetryMaxDelay() time.Duration {
+	return c.retryMaxDelay
+}
+
+// BreakerThreshold returns the number of consecutive failed requests after
+// which an instance is considered unreachable. Zero disables the breaker.
+func (c *Config) BreakerThreshold() int {
+	return c.breakerThreshold
+}
+
+// BreakerCooldown returns how long requests to an unreachable instance fail
+// fast before it is tried again.
+func (c *Config) BreakerCooldown() time.Duration {
+	return c.breakerCooldown
+}
+
+// CacheLookupTTL returns how long series and movie lookups are cached.
+func (c *Config) CacheLookupTTL() time.Duration {
+	return c.cacheLookupTTL
+}
+
+// CacheMetadataTTL returns how long tags and quality profiles are cached.
+func (c *Config) CacheMetadataTTL() time.Duration {
+	return c.cacheMetadataTTL
+}
+
+// RateLimit returns the number of requests per second allowed to each
+// instance. Zero disables the limit.
+func (c *Config) RateLimit() int {
+	return c.rateLimit
+}
+
+// RateBurst returns the number of requests that may exceed the rate limit in
+// a burst.
+func (c *Config) RateBurst() int {
+	return c.rateBurst
+}
+
+// MaxInFlight returns the number of concurrent requests allowed to each
+// instance. Zero disables the limit.
+func (c *Config) MaxInFlight() int {
+	return c.maxInFlight
+}
+
+// SonarrHTTP returns the connection settings for Sonarr.
+func (c *Config) SonarrHTTP() HTTPSettings {
+	return c.sonarrHTTP
+}
+
+// RadarrHTTP returns the connection settings for Radarr.
+func (c *Config) RadarrHTTP() HTTPSettings {
+	return c.radarrHTTP
+}
+
+// httpSettings reads the connection settings of the instance whose variables
+// start with prefix, e.g. SONARR_CA_FILE.
+func httpSettings(prefix string) HTTPSettings {
+	return HTTPSettings{
+		CAFile:             os.Getenv(prefix + "_CA_FILE"),
+		ClientCertFile:     os.Getenv(prefix + "_CLIENT_CERT_FILE"),
+		ClientKeyFile:      os.Getenv(prefix + "_CLIENT_KEY_FILE"),
+		InsecureSkipVerify: envBoolWithDefault(prefix+"_TLS_SKIP_VERIFY", false),
+		ProxyURL:           os.Getenv(prefix + "_PROXY_URL"),
+		BasicAuthUser:      os.Getenv(prefix + "_BASIC_AUTH_USER"),
+		BasicAuthPassword:  os.Getenv(prefix + "_BASIC_AUTH_PASSWORD"),
+		Headers:            parseHeaders(os.Getenv(prefix + "_HEADERS")),
+	}
+}
+
+// splitList parses a comma separated list, skipping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseHeaders parses comma separated "Name=value" pairs.
+func parseHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
 func envWithDefault(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -94,3 +447,25 @@ func envIntWithDefault(key string, defaultValue int) int {
 
 	return intValue
 }
+
+func envBoolWithDefault(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func envDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return duration
+}
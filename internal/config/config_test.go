@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestEnvWithDefault(t *testing.T) {
@@ -38,3 +39,37 @@ func TestEnvIntWithDefault(t *testing.T) {
 		t.Errorf("Expected 10 for unset variable, got %d", value)
 	}
 }
+
+func TestEnvDurationWithDefault(t *testing.T) {
+	os.Setenv("TEST_DURATION_VAR", "90s")
+	value := envDurationWithDefault("TEST_DURATION_VAR", time.Minute)
+	if value != 90*time.Second {
+		t.Errorf("Expected 90s, got %s", value)
+	}
+
+	os.Setenv("TEST_DURATION_VAR", "soon")
+	value = envDurationWithDefault("TEST_DURATION_VAR", time.Minute)
+	if value != time.Minute {
+		t.Errorf("Expected 1m for invalid input, got %s", value)
+	}
+
+	os.Unsetenv("TEST_DURATION_VAR")
+	value = envDurationWithDefault("TEST_DURATION_VAR", time.Minute)
+	if value != time.Minute {
+		t.Errorf("Expected 1m for unset variable, got %s", value)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers := parseHeaders("CF-Access-Client-Id=id, CF-Access-Client-Secret = secret,invalid,=empty")
+
+	if len(headers) != 2 {
+		t.Errorf("Expected 2 headers, got %d", len(headers))
+	}
+	if headers["CF-Access-Client-Id"] != "id" {
+		t.Errorf("Expected CF-Access-Client-Id to be 'id', got '%s'", headers["CF-Access-Client-Id"])
+	}
+	if headers["CF-Access-Client-Secret"] != "secret" {
+		t.Errorf("Expected CF-Access-Client-Secret to be 'secret', got '%s'", headers["CF-Access-Client-Secret"])
+	}
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types reported by Sonarr and Radarr Connect webhooks.
+const (
+	TypeGrab        = "Grab"
+	TypeDownload    = "Download"
+	TypeUpgrade     = "Upgrade"
+	TypeHealthIssue = "HealthIssue"
+	TypeDelete      = "Delete"
+)
+
+// Event types reported by mcparr itself for the approval queue.
+const (
+	TypeRequestPending  = "RequestPending"
+	TypeRequestApproved = "RequestApproved"
+	TypeRequestDenied   = "RequestDenied"
+)
+
+// Event is a notable change reported by one of the arr instances.
+type Event struct {
+	ID      int64     `json:"id"`
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Type    string    `json:"type"`
+	Title   string    `json:"title,omitempty"`
+	MediaID int       `json:"mediaId,omitempty"`
+	Message string    `json:"message"`
+}
+
+// Log keeps a bounded buffer of the most recent events and fans new events
+// out to subscribers.
+type Log struct {
+	mu          sync.Mutex
+	events      []Event
+	size        int
+	nextID      int64
+	subscribers []func(Event)
+}
+
+// NewLog creates a new Log that keeps at most size events.
+func NewLog(size int) *Log {
+	if size <= 0 {
+		size = 1
+	}
+	return &Log{
+		events: make([]Event, 0, size),
+		size:   size,
+	}
+}
+
+// Add records an event, assigning its ID and time, and notifies subscribers.
+func (l *Log) Add(event Event) Event {
+	l.mu.Lock()
+	l.nextID++
+	event.ID = l.nextID
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if len(l.events) == l.size {
+		copy(l.events, l.events[1:])
+		l.events = l.events[:len(l.events)-1]
+	}
+	l.events = append(l.events, event)
+
+	subscribers := make([]func(Event), len(l.subscribers))
+	copy(subscribers, l.subscribers)
+	l.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+
+	return event
+}
+
+// Recent returns up to limit events, newest first.
+func (l *Log) Recent(limit int) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit <= 0 || limit > len(l.events) {
+		limit = len(l.events)
+	}
+
+	recent := make([]Event, 0, limit)
+	for i := len(l.events) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, l.events[i])
+	}
+
+	return recent
+}
+
+// Subscribe registers fn to be called for every event added after this call.
+func (l *Log) Subscribe(fn func(Event)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, fn)
+}
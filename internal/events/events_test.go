@@ -0,0 +1,41 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestLogRecent(t *testing.T) {
+	log := NewLog(2)
+
+	log.Add(Event{Message: "first"})
+	log.Add(Event{Message: "second"})
+	log.Add(Event{Message: "third"})
+
+	recent := log.Recent(0)
+	if len(recent) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(recent))
+	}
+
+	if recent[0].Message != "third" || recent[1].Message != "second" {
+		t.Errorf("Expected newest events first, got '%s', '%s'", recent[0].Message, recent[1].Message)
+	}
+
+	if recent[0].ID != 3 {
+		t.Errorf("Expected event ID 3, got %d", recent[0].ID)
+	}
+}
+
+func TestLogSubscribe(t *testing.T) {
+	log := NewLog(10)
+
+	var received []Event
+	log.Subscribe(func(e Event) {
+		received = append(received, e)
+	})
+
+	log.Add(Event{Type: TypeGrab})
+
+	if len(received) != 1 || received[0].Type != TypeGrab {
+		t.Errorf("Expected subscriber to receive the Grab event, got %v", received)
+	}
+}
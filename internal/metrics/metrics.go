@@ -0,0 +1,185 @@
+// Package metrics exports Prometheus metrics about tool calls and the
+// requests made to Sonarr and Radarr.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/IdoKendo/mcparr/pkg/client"
+)
+
+const namespace = "mcparr"
+
+// breakerStates are the values of the circuit breaker state gauge.
+var breakerStates = []string{"closed", "open", "half-open"}
+
+// Instance is a Sonarr or Radarr client whose state is exported.
+type Instance interface {
+	BreakerState() string
+	LimiterStats() client.LimiterStats
+}
+
+// Metrics holds the Prometheus collectors of mcparr. It implements
+// client.Observer.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	toolCalls        *prometheus.CounterVec
+	toolDuration     *prometheus.HistogramVec
+	upstreamRequests *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+	cacheLookups     *prometheus.CounterVec
+}
+
+// New creates a new Metrics with its own registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_calls_total",
+			Help:      "Tool calls by tool and result: ok, error (the tool reported a failure) or failure (the call itself failed).",
+		}, []string{"tool", "result"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Tool call latency by tool.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tool"}),
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Requests to Sonarr and Radarr by instance, endpoint, method and HTTP status (0 if no response was received).",
+		}, []string{"instance", "endpoint", "method", "status"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of requests to Sonarr and Radarr by instance and endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"instance", "endpoint"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Response cache lookups by instance, endpoint and result (hit or miss).",
+		}, []string{"instance", "endpoint", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.toolCalls,
+		m.toolDuration,
+		m.upstreamRequests,
+		m.upstreamDuration,
+		m.cacheLookups,
+	)
+
+	return m
+}
+
+// RegisterInstance exports the circuit breaker state and rate limiter wait
+// times of an instance.
+func (m *Metrics) RegisterInstance(name string, instance Instance) {
+	labels := prometheus.Labels{"instance": name}
+
+	for _, state := range breakerStates {
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "circuit_breaker_state",
+			Help:        "1 if the circuit breaker of the instance is in the given state, 0 otherwise.",
+			ConstLabels: prometheus.Labels{"instance": name, "state": state},
+		}, func() float64 {
+			if instance.BreakerState() == state {
+				return 1
+			}
+			return 0
+		}))
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "limiter_delayed_requests_total",
+			Help:        "Requests that waited for the rate or concurrency limit of the instance.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(instance.LimiterStats().Delayed)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "limiter_wait_seconds_total",
+			Help:        "Time spent waiting for the rate or concurrency limit of the instance.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return instance.LimiterStats().TotalWait.Seconds()
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "limiter_max_wait_seconds",
+			Help:        "Longest a single request waited for the limits of the instance.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return instance.LimiterStats().MaxWait.Seconds()
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "upstream_requests_in_flight",
+			Help:        "Requests currently being sent to the instance.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(instance.LimiterStats().InFlight)
+		}),
+	)
+}
+
+// ObserveRequest implements client.Observer.
+func (m *Metrics) ObserveRequest(instance, method, endpoint string, statusCode int, duration time.Duration) {
+	m.upstreamRequests.WithLabelValues(instance, endpoint, method, strconv.Itoa(statusCode)).Inc()
+	m.upstreamDuration.WithLabelValues(instance, endpoint).Observe(duration.Seconds())
+}
+
+// ObserveCache implements client.Observer.
+func (m *Metrics) ObserveCache(instance, endpoint string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(instance, endpoint, result).Inc()
+}
+
+// ToolMiddleware records the count, result and latency of every tool call.
+func (m *Metrics) ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+
+			tool := request.Params.Name
+			m.toolDuration.WithLabelValues(tool).Observe(time.Since(start).Seconds())
+			switch {
+			case err != nil:
+				m.toolCalls.WithLabelValues(tool, "failure").Inc()
+			case result != nil && result.IsError:
+				m.toolCalls.WithLabelValues(tool, "error").Inc()
+			default:
+				m.toolCalls.WithLabelValues(tool, "ok").Inc()
+			}
+
+			return result, err
+		}
+	}
+}
+
+// Handler returns the HTTP handler serving the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/IdoKendo/mcparr/pkg/client"
+)
+
+type mockInstance struct {
+	state string
+}
+
+func (m *mockInstance) BreakerState() string { return m.state }
+
+func (m *mockInstance) LimiterStats() client.LimiterStats {
+	return client.LimiterStats{Requests: 3, Delayed: 2, TotalWait: 1500 * time.Millisecond}
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	m.RegisterInstance("Radarr", &mockInstance{state: "open"})
+	m.ObserveRequest("Radarr", "GET", "movie/{id}", 503, 20*time.Millisecond)
+	m.ObserveCache("Radarr", "movie/lookup", true)
+
+	handler := m.ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("Radarr is unreachable"), nil
+	})
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "request_download"
+	handler(context.Background(), request)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+
+	expected := []string{
+		`mcparr_tool_calls_total{result="error",tool="request_download"} 1`,
+		`mcparr_tool_call_duration_seconds_count{tool="request_download"} 1`,
+		`mcparr_upstream_requests_total{endpoint="movie/{id}",instance="Radarr",method="GET",status="503"} 1`,
+		`mcparr_cache_lookups_total{endpoint="movie/lookup",instance="Radarr",result="hit"} 1`,
+		`mcparr_circuit_breaker_state{instance="Radarr",state="open"} 1`,
+		`mcparr_circuit_breaker_state{instance="Radarr",state="closed"} 0`,
+		`mcparr_limiter_wait_seconds_total{instance="Radarr"} 1.5`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("Expected metrics to contain '%s'", line)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Content restricts the movies and series a client may see and add. The
+// zero value allows everything.
+type Content struct {
+	// MaxCertification is the highest allowed rating, on either the US
+	// movie scale (G, PG, PG-13, R, NC-17) or the TV scale (TV-Y, TV-Y7,
+	// TV-G, TV-PG, TV-14, TV-MA). Titles without a known rating are not
+	// allowed once it is set.
+	MaxCertification string `json:"max_certification"`
+	// BlockedGenres are genres that may not be seen.
+	BlockedGenres []string `json:"blocked_genres"`
+	// BlockedKeywords may not appear in the title or overview.
+	BlockedKeywords []string `json:"blocked_keywords"`
+}
+
+// certificationLevels puts the movie and TV ratings on a single scale, so a
+// limit given on one scale applies to the other.
+var certificationLevels = map[string]int{
+	"G":     0,
+	"TV-Y":  0,
+	"TV-G":  0,
+	"TV-Y7": 1,
+	"PG":    2,
+	"TV-PG": 2,
+	"PG-13": 3,
+	"TV-14": 3,
+	"R":     4,
+	"TV-MA": 4,
+	"NC-17": 5,
+}
+
+// CertificationLevel returns the position of a rating on the combined movie
+// and TV scale, or false if the rating is unknown.
+func CertificationLevel(certification string) (int, bool) {
+	level, ok := certificationLevels[strings.ToUpper(strings.TrimSpace(certification))]
+	return level, ok
+}
+
+// Empty reports whether c allows everything.
+func (c Content) Empty() bool {
+	return c.MaxCertification == "" && len(c.BlockedGenres) == 0 && len(c.BlockedKeywords) == 0
+}
+
+// Check returns why a title with the given rating, genres and text is not
+// allowed, or an empty string if it is.
+func (c Content) Check(certification string, genres []string, title, overview string) string {
+	if c.MaxCertification != "" {
+		limit, ok := CertificationLevel(c.MaxCertification)
+		level, rated := CertificationLevel(certification)
+		switch {
+		case !ok:
+			return fmt.Sprintf("the maximum rating %s is not a known rating", c.MaxCertification)
+		case !rated:
+			return "it has no known rating"
+		case level > limit:
+			return fmt.Sprintf("it is rated %s, above %s", certification, c.MaxCertification)
+		}
+	}
+
+	for _, blocked := range c.BlockedGenres {
+		for _, genre := range genres {
+			if strings.EqualFold(genre, blocked) {
+				return fmt.Sprintf("the %s genre is blocked", genre)
+			}
+		}
+	}
+
+	text := strings.ToLower(title + "\n" + overview)
+	for _, keyword := range c.BlockedKeywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return fmt.Sprintf("it mentions the blocked keyword %q", keyword)
+		}
+	}
+
+	return ""
+}
+
+// ContentFor returns the content rules of client: its own if it has any,
+// otherwise the global rules.
+func (p *Policy) ContentFor(client string) Content {
+	if rules, ok := p.clientRules(client); ok && rules.Content != nil {
+		return *rules.Content
+	}
+	if p.Content != nil {
+		return *p.Content
+	}
+	return Content{}
+}
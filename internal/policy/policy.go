@@ -0,0 +1,136 @@
+// Package policy decides which tools each MCP client may call.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+)
+
+// Rules restrict the tools that may be called.
+type Rules struct {
+	// ReadOnly denies every tool that changes the library.
+	ReadOnly bool `json:"read_only"`
+	// Allow, if not empty, is the only tools that may be called.
+	Allow []string `json:"allow"`
+	// Deny is tools that may not be called.
+	Deny []string `json:"deny"`
+	// Quota, if set, limits how much may be added to the library.
+	Quota *Quota `json:"quota,omitempty"`
+	// Content, if set, restricts the movies and series that may be seen and
+	// added.
+	Content *Content `json:"content,omitempty"`
+}
+
+// Quota limits how many movies and series a client may add per quota
+// period. Zero is unlimited.
+type Quota struct {
+	Movies int `json:"movies"`
+	Series int `json:"series"`
+}
+
+// Policy holds the rules applying to every client, and extra rules for
+// specific clients identified by audit.User.
+type Policy struct {
+	Rules
+	Clients map[string]Rules `json:"clients"`
+}
+
+// Load reads a policy from a JSON file such as:
+//
+//	{
+//	  "deny": ["request_delete"],
+//	  "clients": {
+//	    "kids-tablet": {"read_only": true, "content": {"max_certification": "PG"}},
+//	    "cursor": {"quota": {"movies": 10, "series": 3}}
+//	  }
+//	}
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	return &p, nil
+}
+
+// Check returns why client may not call tool, or an empty string if it may.
+// mutating reports whether the tool changes the library.
+func (p *Policy) Check(client, tool string, mutating bool) string {
+	if reason := p.Rules.check(tool, mutating); reason != "" {
+		return reason
+	}
+
+	if rules, ok := p.clientRules(client); ok {
+		return rules.check(tool, mutating)
+	}
+	return ""
+}
+
+// QuotaFor returns the quota of client: its own if it has one, otherwise the
+// global quota.
+func (p *Policy) QuotaFor(client string) Quota {
+	if rules, ok := p.clientRules(client); ok && rules.Quota != nil {
+		return *rules.Quota
+	}
+	if p.Quota != nil {
+		return *p.Quota
+	}
+	return Quota{}
+}
+
+func (p *Policy) clientRules(client string) (Rules, bool) {
+	for name, rules := range p.Clients {
+		if strings.EqualFold(name, client) {
+			return rules, true
+		}
+	}
+	return Rules{}, false
+}
+
+func (r Rules) check(tool string, mutating bool) string {
+	switch {
+	case r.ReadOnly && mutating:
+		return "MCParr is in read-only mode"
+	case slices.Contains(r.Deny, tool):
+		return fmt.Sprintf("the %s tool is denied", tool)
+	case len(r.Allow) > 0 && !slices.Contains(r.Allow, tool):
+		return fmt.Sprintf("the %s tool is not in the allow list", tool)
+	default:
+		return ""
+	}
+}
+
+// Wrap returns tools with handlers that enforce the policy. mutates reports
+// whether a call changes the library. Denied calls return a policy error and
+// are recorded in the audit log.
+func (p *Policy) Wrap(tools []server.ServerTool, mutates func(request mcp.CallToolRequest) bool) []server.ServerTool {
+	wrapped := make([]server.ServerTool, len(tools))
+	for i, t := range tools {
+		next := t.Handler
+		t.Handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			client := audit.User(ctx)
+			if reason := p.Check(client, request.Params.Name, mutates(request)); reason != "" {
+				audit.Deny(ctx, reason)
+				return mcp.NewToolResultError(fmt.Sprintf(
+					"Policy error: %s is not allowed to call %s because %s. Do not retry; tell the user this action is not permitted.",
+					client, request.Params.Name, reason)), nil
+			}
+			return next(ctx, request)
+		}
+		wrapped[i] = t
+	}
+	return wrapped
+}
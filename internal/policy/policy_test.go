@@ -0,0 +1,174 @@
+package policy
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+)
+
+func TestCheck(t *testing.T) {
+	p := &Policy{
+		Rules: Rules{Deny: []string{"request_delete"}},
+		Clients: map[string]Rules{
+			"kids-tablet": {ReadOnly: true},
+			"cursor":      {Allow: []string{"search_media_id"}},
+		},
+	}
+
+	tests := []struct {
+		client   string
+		tool     string
+		mutating bool
+		allowed  bool
+	}{
+		{"claude-desktop", "request_download", true, true},
+		{"claude-desktop", "request_delete", true, false},
+		{"Kids-Tablet", "request_download", true, false},
+		{"kids-tablet", "search_media_id", false, true},
+		{"cursor", "search_media_id", false, true},
+		{"cursor", "recent_events", false, false},
+	}
+
+	for _, tt := range tests {
+		reason := p.Check(tt.client, tt.tool, tt.mutating)
+		if (reason == "") != tt.allowed {
+			t.Errorf("Expected %s calling %s to be allowed=%v, got reason %q", tt.client, tt.tool, tt.allowed, reason)
+		}
+	}
+
+	readOnly := &Policy{Rules: Rules{ReadOnly: true}}
+	if readOnly.Check("anyone", "request_download", true) == "" {
+		t.Error("Expected read-only mode to deny mutating tools")
+	}
+}
+
+func TestQuotaFor(t *testing.T) {
+	p := &Policy{
+		Rules:   Rules{Quota: &Quota{Movies: 10, Series: 3}},
+		Clients: map[string]Rules{"kids-tablet": {Quota: &Quota{Movies: 2}}},
+	}
+
+	if q := p.QuotaFor("Kids-Tablet"); q.Movies != 2 || q.Series != 0 {
+		t.Errorf("Expected the client quota, got %+v", q)
+	}
+	if q := p.QuotaFor("cursor"); q.Movies != 10 || q.Series != 3 {
+		t.Errorf("Expected the global quota, got %+v", q)
+	}
+	if q := (&Policy{}).QuotaFor("cursor"); q != (Quota{}) {
+		t.Errorf("Expected no quota, got %+v", q)
+	}
+}
+
+func TestContentCheck(t *testing.T) {
+	rules := Content{
+		MaxCertification: "PG-13",
+		BlockedGenres:    []string{"Horror"},
+		BlockedKeywords:  []string{"zombie"},
+	}
+
+	tests := []struct {
+		name          string
+		certification string
+		genres        []string
+		overview      string
+		allowed       bool
+	}{
+		{"movie rating below", "PG", nil, "", true},
+		{"movie rating above", "R", nil, "", false},
+		{"tv rating at limit", "TV-14", nil, "", true},
+		{"tv rating above", "TV-MA", nil, "", false},
+		{"unrated", "", nil, "", false},
+		{"blocked genre", "PG", []string{"Comedy", "horror"}, "", false},
+		{"blocked keyword", "PG", nil, "A Zombie outbreak", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := rules.Check(tt.certification, tt.genres, "Title", tt.overview)
+			if (reason == "") != tt.allowed {
+				t.Errorf("Expected allowed=%v, got reason %q", tt.allowed, reason)
+			}
+		})
+	}
+
+	if reason := (Content{}).Check("NC-17", nil, "", ""); reason != "" {
+		t.Errorf("Expected empty rules to allow everything, got %q", reason)
+	}
+
+	p := &Policy{Clients: map[string]Rules{"kids-tablet": {Content: &rules}}}
+	if p.ContentFor("kids-tablet").MaxCertification != "PG-13" || !p.ContentFor("cursor").Empty() {
+		t.Error("Expected only kids-tablet to have content rules")
+	}
+}
+
+type memoryRecorder struct {
+	entries []audit.Entry
+}
+
+func (r *memoryRecorder) Record(e audit.Entry) error {
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func TestWrapDeniesAndAudits(t *testing.T) {
+	var called bool
+	tools := []server.ServerTool{{
+		Tool: mcp.NewTool("request_download"),
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			called = true
+			return mcp.NewToolResultText("ok"), nil
+		},
+	}}
+
+	actions := map[string]string{"request_download": audit.ActionAdd}
+	mutates := func(request mcp.CallToolRequest) bool {
+		return request.Params.Name == "request_download"
+	}
+	wrapped := (&Policy{Rules: Rules{ReadOnly: true}}).Wrap(tools, mutates)
+
+	recorder := &memoryRecorder{}
+	handler := audit.Middleware(recorder, actions, log.Default())(wrapped[0].Handler)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "request_download"
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if called {
+		t.Error("Expected the denied tool not to be called")
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "read-only mode") {
+		t.Errorf("Expected a read-only policy error, got %v", result.Content)
+	}
+	if len(recorder.entries) != 1 || recorder.entries[0].Outcome != audit.OutcomeDenied {
+		t.Errorf("Expected the denied call to be audited, got %+v", recorder.entries)
+	}
+
+	request.Params.Name = "search"
+	if result, _ := wrapped[0].Handler(context.Background(), request); result.IsError || !called {
+		t.Errorf("Expected read-only mode to allow calls that change nothing, got %v", result.Content)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(path, []byte(`{"deny": ["request_delete"], "clients": {"kids-tablet": {"read_only": true}}}`), 0600)
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(p.Deny) != 1 || !p.Clients["kids-tablet"].ReadOnly {
+		t.Errorf("Expected the policy to be loaded, got %+v", p)
+	}
+}
@@ -0,0 +1,149 @@
+// Package shutdown lets in-flight tool calls finish when the server is asked
+// to stop.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ErrStopped is the cause the server's context is cancelled with when the
+// server stops, which tells it apart from a client cancelling a call.
+var ErrStopped = errors.New("server is shutting down")
+
+// Drainer tracks in-flight tool calls. Once draining, it rejects new calls
+// and shields running ones from the server's own cancellation, so they can
+// finish until the shutdown deadline. Cancellation requested by the client
+// with notifications/cancelled is still passed on until then.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	calls    map[int64]string
+	nextID   int64
+	done     chan struct{}
+
+	// abort is cancelled when the shutdown deadline is reached.
+	abort       context.Context
+	cancelAbort context.CancelFunc
+}
+
+// NewDrainer creates a new Drainer.
+func NewDrainer() *Drainer {
+	abort, cancel := context.WithCancel(context.Background())
+	return &Drainer{
+		calls:       make(map[int64]string),
+		abort:       abort,
+		cancelAbort: cancel,
+	}
+}
+
+// Middleware tracks tool calls and rejects them while draining.
+func (d *Drainer) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id, ok := d.begin(request.Params.Name)
+			if !ok {
+				return mcp.NewToolResultError("MCParr is shutting down and is not accepting new requests"), nil
+			}
+			defer d.end(id)
+
+			callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			defer cancel()
+			stopParent := context.AfterFunc(ctx, func() {
+				// Only the server stopping is held back; a call the client
+				// cancelled ends even while draining.
+				if !d.Draining() || !errors.Is(context.Cause(ctx), ErrStopped) {
+					cancel()
+				}
+			})
+			defer stopParent()
+			stopAbort := context.AfterFunc(d.abort, cancel)
+			defer stopAbort()
+
+			return next(callCtx, request)
+		}
+	}
+}
+
+// Draining reports whether Stop has been called.
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Stop makes the drainer reject new tool calls and shield running ones from
+// the server's cancellation. It must be called before the server's context
+// is cancelled, which must be with ErrStopped as the cause.
+func (d *Drainer) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return
+	}
+	d.draining = true
+	d.done = make(chan struct{})
+	if len(d.calls) == 0 {
+		close(d.done)
+	}
+}
+
+// Wait waits for the running tool calls to finish after Stop. If ctx is done
+// first, the calls are cancelled and their tool names are returned.
+func (d *Drainer) Wait(ctx context.Context) []string {
+	d.mu.Lock()
+	done := d.done
+	d.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	abandoned := make([]string, 0, len(d.calls))
+	for _, name := range d.calls {
+		abandoned = append(abandoned, name)
+	}
+	d.mu.Unlock()
+	slices.Sort(abandoned)
+
+	d.cancelAbort()
+	return abandoned
+}
+
+func (d *Drainer) begin(name string) (int64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return 0, false
+	}
+	d.nextID++
+	d.calls[d.nextID] = name
+	return d.nextID, true
+}
+
+func (d *Drainer) end(id int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.calls, id)
+	if d.done != nil && len(d.calls) == 0 {
+		select {
+		case <-d.done:
+		default:
+			close(d.done)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package shutdown
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func callTool(d *Drainer, ctx context.Context, name string, handler func(ctx context.Context) error) (*mcp.CallToolResult, error) {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	return d.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := handler(ctx); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText("done"), nil
+	})(ctx, request)
+}
+
+func TestDrainerLetsRunningCallsFinish(t *testing.T) {
+	d := NewDrainer()
+	serverCtx, cancelServer := context.WithCancelCause(context.Background())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	results := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, _ := callTool(d, serverCtx, "request_download", func(ctx context.Context) error {
+			close(started)
+			<-release
+			return ctx.Err()
+		})
+		results <- result
+	}()
+	<-started
+
+	d.Stop()
+	cancelServer(ErrStopped)
+
+	rejected, _ := callTool(d, context.Background(), "search_media_id", func(ctx context.Context) error { return nil })
+	if !rejected.IsError {
+		t.Error("Expected new calls to be rejected while draining")
+	}
+
+	close(release)
+	if abandoned := d.Wait(context.Background()); len(abandoned) != 0 {
+		t.Errorf("Expected no abandoned calls, got %v", abandoned)
+	}
+	if result := <-results; result.IsError {
+		t.Errorf("Expected the running call not to be cancelled by the server shutdown, got %v", result.Content)
+	}
+}
+
+func TestDrainerPassesOnClientCancellation(t *testing.T) {
+	d := NewDrainer()
+	serverCtx, cancelServer := context.WithCancelCause(context.Background())
+	defer cancelServer(ErrStopped)
+	// The server cancels the context of a single request when the client
+	// sends notifications/cancelled for it.
+	requestCtx, cancelRequest := context.WithCancel(serverCtx)
+
+	started := make(chan struct{})
+	results := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, _ := callTool(d, requestCtx, "request_download", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		results <- result
+	}()
+	<-started
+
+	d.Stop()
+	cancelRequest()
+
+	select {
+	case result := <-results:
+		if !result.IsError {
+			t.Error("Expected the cancelled call to end with an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the call cancelled by the client to end while draining")
+	}
+}
+
+func TestDrainerCancelsCallsAtDeadline(t *testing.T) {
+	d := NewDrainer()
+
+	started := make(chan struct{})
+	results := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, _ := callTool(d, context.Background(), "request_download", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		results <- result
+	}()
+	<-started
+
+	d.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	abandoned := d.Wait(ctx)
+	if len(abandoned) != 1 || abandoned[0] != "request_download" {
+		t.Errorf("Expected request_download to be abandoned, got %v", abandoned)
+	}
+	if result := <-results; !result.IsError {
+		t.Error("Expected the abandoned call to be cancelled")
+	}
+}
+
+func TestDrainerPropagatesClientCancellation(t *testing.T) {
+	d := NewDrainer()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	results := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, _ := callTool(d, ctx, "search_media_id", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		results <- result
+	}()
+	<-started
+
+	// Cancelling the request context outside of a shutdown is what
+	// notifications/cancelled does.
+	cancel()
+
+	select {
+	case result := <-results:
+		if !result.IsError {
+			t.Error("Expected the cancelled call to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the call to be cancelled")
+	}
+}
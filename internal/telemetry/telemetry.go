@@ -0,0 +1,101 @@
+// Package telemetry sets up OpenTelemetry tracing of tool calls and the
+// Sonarr and Radarr requests they make.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/IdoKendo/mcparr/internal/toolresult"
+)
+
+// Supported exporters. The collector endpoint, headers and TLS settings are
+// read by the exporters from the standard OTEL_EXPORTER_OTLP_* variables.
+const (
+	ExporterNone     = ""
+	ExporterOTLPHTTP = "otlphttp"
+	ExporterOTLPGRPC = "otlpgrpc"
+)
+
+var tracer = otel.Tracer("github.com/IdoKendo/mcparr/internal/telemetry")
+
+// Setup installs a global tracer provider exporting to the given exporter and
+// returns a function that flushes and stops it. With ExporterNone tracing
+// stays disabled and the returned function does nothing.
+func Setup(ctx context.Context, exporter, serviceVersion string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLPHTTP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterOTLPGRPC:
+		spanExporter, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, must be %q or %q", exporter, ExporterOTLPHTTP, ExporterOTLPGRPC)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("mcparr"),
+		semconv.ServiceVersion(serviceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// ToolMiddleware starts a span per tool call. The requests the tool makes to
+// Sonarr and Radarr become its child spans.
+func ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, span := tracer.Start(ctx, "tools/call "+request.Params.Name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attribute.String("mcp.tool.name", request.Params.Name)),
+			)
+			defer span.End()
+
+			result, err := next(ctx, request)
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case result != nil && result.IsError:
+				message := toolresult.Text(result)
+				if message == "" {
+					message = "tool returned an error"
+				}
+				span.SetStatus(codes.Error, message)
+			}
+
+			return result, err
+		}
+	}
+}
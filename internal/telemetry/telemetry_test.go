@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/IdoKendo/mcparr/pkg/client"
+)
+
+func TestToolMiddlewareTracesClientRequests(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	radarr := client.NewRadarrClient(server.URL, "secret-api-key")
+	handler := ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if _, err := radarr.GetMovie(ctx, 42); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "search_media_id"
+	handler(context.Background(), request)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	child, parent := spans[0], spans[1]
+	if parent.Name != "tools/call search_media_id" {
+		t.Errorf("Expected parent span 'tools/call search_media_id', got '%s'", parent.Name)
+	}
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+		t.Error("Expected the client request span to be a child of the tool call span")
+	}
+	if child.Name != "GET movie" {
+		t.Errorf("Expected child span 'GET movie', got '%s'", child.Name)
+	}
+
+	attributes := make(map[string]string)
+	for _, attr := range child.Attributes {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+		if strings.Contains(attr.Value.Emit(), "secret-api-key") {
+			t.Errorf("Expected the API key not to be recorded, found it in %s", attr.Key)
+		}
+	}
+	if attributes["mcparr.instance"] != "Radarr" {
+		t.Errorf("Expected instance 'Radarr', got '%s'", attributes["mcparr.instance"])
+	}
+	if attributes["http.response.status_code"] != "404" {
+		t.Errorf("Expected status code 404, got '%s'", attributes["http.response.status_code"])
+	}
+	if strings.Contains(child.Status.Description, "secret-api-key") || strings.Contains(parent.Status.Description, "secret-api-key") {
+		t.Error("Expected the API key not to be recorded in span status")
+	}
+}
@@ -0,0 +1,18 @@
+// Package toolresult reads the results of MCP tool calls.
+package toolresult
+
+import "github.com/mark3labs/mcp-go/mcp"
+
+// Text returns the first text content of result, or an empty string if it
+// has none.
+func Text(result *mcp.CallToolResult) string {
+	if result == nil {
+		return ""
+	}
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
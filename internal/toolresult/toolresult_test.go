@@ -0,0 +1,19 @@
+package toolresult
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestText(t *testing.T) {
+	if got := Text(mcp.NewToolResultError("Failed to add")); got != "Failed to add" {
+		t.Errorf("Expected 'Failed to add', got '%s'", got)
+	}
+	if got := Text(&mcp.CallToolResult{}); got != "" {
+		t.Errorf("Expected no text, got '%s'", got)
+	}
+	if got := Text(nil); got != "" {
+		t.Errorf("Expected no text for a nil result, got '%s'", got)
+	}
+}
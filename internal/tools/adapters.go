@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"time"
 
 	"github.com/IdoKendo/mcparr/pkg/client"
 )
@@ -23,29 +24,12 @@ func (a *SonarrClientAdapter) LookupSeries(ctx context.Context, name string) ([]
 		return nil, err
 	}
 
-	series := make([]Series, len(clientSeries))
-	for i, s := range clientSeries {
-		series[i] = Series{
-			ID:       s.ID,
-			Title:    s.Title,
-			Overview: s.Overview,
-			Genres:   s.Genres,
-		}
-	}
-
-	return series, nil
+	return fromClientSeriesList(clientSeries), nil
 }
 
 // RequestSeriesDownload adapts the client.SonarrClient.RequestSeriesDownload method.
 func (a *SonarrClientAdapter) RequestSeriesDownload(ctx context.Context, series Series, qualityProfileID int, rootFolderPath string) error {
-	clientSeries := client.Series{
-		ID:       series.ID,
-		Title:    series.Title,
-		Overview: series.Overview,
-		Genres:   series.Genres,
-	}
-
-	return a.client.RequestSeriesDownload(ctx, clientSeries, qualityProfileID, rootFolderPath)
+	return a.client.RequestSeriesDownload(ctx, toClientSeries(series), qualityProfileID, rootFolderPath)
 }
 
 // SearchSeriesByGenre adapts the client.SonarrClient.SearchSeriesByGenre method.
@@ -55,17 +39,7 @@ func (a *SonarrClientAdapter) SearchSeriesByGenre(ctx context.Context, genre str
 		return nil, err
 	}
 
-	series := make([]Series, len(clientSeries))
-	for i, s := range clientSeries {
-		series[i] = Series{
-			ID:       s.ID,
-			Title:    s.Title,
-			Overview: s.Overview,
-			Genres:   s.Genres,
-		}
-	}
-
-	return series, nil
+	return fromClientSeriesList(clientSeries), nil
 }
 
 // RequestSeriesDelete adapts the client.SonarrClient.RequestSeriesDelete method.
@@ -77,6 +51,114 @@ func (a *SonarrClientAdapter) RequestSeriesDelete(ctx context.Context, series Se
 	return a.client.RequestSeriesDelete(ctx, clientSeries)
 }
 
+// ListSeries adapts the client.SonarrClient.ListSeries method.
+func (a *SonarrClientAdapter) ListSeries(ctx context.Context) ([]Series, error) {
+	clientSeries, err := a.client.ListSeries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientSeriesList(clientSeries), nil
+}
+
+// GetSeries adapts the client.SonarrClient.GetSeries method.
+func (a *SonarrClientAdapter) GetSeries(ctx context.Context, tvdbID int) (Series, error) {
+	clientSeries, err := a.client.GetSeries(ctx, tvdbID)
+	if err != nil {
+		return Series{}, err
+	}
+
+	return fromClientSeries(clientSeries), nil
+}
+
+// Calendar adapts the client.SonarrClient.Calendar method.
+func (a *SonarrClientAdapter) Calendar(ctx context.Context, start, end time.Time) ([]Episode, error) {
+	clientEpisodes, err := a.client.Calendar(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	episodes := make([]Episode, len(clientEpisodes))
+	for i, e := range clientEpisodes {
+		episodes[i] = Episode{
+			SeriesTitle:   e.Series.Title,
+			SeriesID:      e.Series.ID,
+			SeasonNumber:  e.SeasonNumber,
+			EpisodeNumber: e.EpisodeNumber,
+			Title:         e.Title,
+			AirDateUTC:    e.AirDateUTC,
+			HasFile:       e.HasFile,
+		}
+	}
+
+	return episodes, nil
+}
+
+// Queue adapts the client.SonarrClient.Queue method.
+func (a *SonarrClientAdapter) Queue(ctx context.Context) ([]QueueItem, error) {
+	clientQueue, err := a.client.Queue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientQueue("sonarr", clientQueue), nil
+}
+
+// Tags adapts the client.SonarrClient.Tags method.
+func (a *SonarrClientAdapter) Tags(ctx context.Context) ([]Tag, error) {
+	clientTags, err := a.client.Tags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientTags(clientTags), nil
+}
+
+// CreateTag adapts the client.SonarrClient.CreateTag method.
+func (a *SonarrClientAdapter) CreateTag(ctx context.Context, label string) (Tag, error) {
+	tag, err := a.client.CreateTag(ctx, label)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	return Tag{ID: tag.ID, Label: tag.Label}, nil
+}
+
+// DiskSpace adapts the client.SonarrClient.DiskSpace method.
+func (a *SonarrClientAdapter) DiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	clientDisks, err := a.client.DiskSpace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientDiskSpace(clientDisks), nil
+}
+
+// EditSeries adapts the client.SonarrClient.EditSeries method.
+func (a *SonarrClientAdapter) EditSeries(ctx context.Context, libraryIDs []int, qualityProfileID int) error {
+	return a.client.EditSeries(ctx, libraryIDs, qualityProfileID)
+}
+
+// QualityProfiles adapts the client.SonarrClient.QualityProfiles method.
+func (a *SonarrClientAdapter) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	clientProfiles, err := a.client.QualityProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientQualityProfiles(clientProfiles), nil
+}
+
+// RootFolders adapts the client.SonarrClient.RootFolders method.
+func (a *SonarrClientAdapter) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	clientFolders, err := a.client.RootFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientRootFolders(clientFolders), nil
+}
+
 // RadarrClientAdapter adapts the client.RadarrClient to tools.RadarrClient.
 type RadarrClientAdapter struct {
 	client *client.RadarrClient
@@ -94,29 +176,12 @@ func (a *RadarrClientAdapter) LookupMovie(ctx context.Context, name string) ([]M
 		return nil, err
 	}
 
-	movies := make([]Movie, len(clientMovies))
-	for i, m := range clientMovies {
-		movies[i] = Movie{
-			ID:       m.ID,
-			Title:    m.Title,
-			Overview: m.Overview,
-			Genres:   m.Genres,
-		}
-	}
-
-	return movies, nil
+	return fromClientMovies(clientMovies), nil
 }
 
 // RequestMovieDownload adapts the client.RadarrClient.RequestMovieDownload method.
 func (a *RadarrClientAdapter) RequestMovieDownload(ctx context.Context, movie Movie, qualityProfileID int, rootFolderPath string) error {
-	clientMovie := client.Movie{
-		ID:       movie.ID,
-		Title:    movie.Title,
-		Overview: movie.Overview,
-		Genres:   movie.Genres,
-	}
-
-	return a.client.RequestMovieDownload(ctx, clientMovie, qualityProfileID, rootFolderPath)
+	return a.client.RequestMovieDownload(ctx, toClientMovie(movie), qualityProfileID, rootFolderPath)
 }
 
 // SearchMoviesByGenre adapts the client.RadarrClient.SearchMoviesByGenre method.
@@ -126,18 +191,10 @@ func (a *RadarrClientAdapter) SearchMoviesByGenre(ctx context.Context, genre str
 		return nil, err
 	}
 
-	movies := make([]Movie, len(clientMovies))
-	for i, m := range clientMovies {
-		movies[i] = Movie{
-			ID:       m.ID,
-			Title:    m.Title,
-			Overview: m.Overview,
-			Genres:   m.Genres,
-		}
-	}
-
-	return movies, nil
+	return fromClientMovies(clientMovies), nil
 }
+
+// RequestMovieDelete adapts the client.RadarrClient.RequestMovieDelete method.
 func (a *RadarrClientAdapter) RequestMovieDelete(ctx context.Context, movie Movie) error {
 	clientMovie := client.Movie{
 		ID:    movie.ID,
@@ -145,3 +202,334 @@ func (a *RadarrClientAdapter) RequestMovieDelete(ctx context.Context, movie Movi
 	}
 	return a.client.RequestMovieDelete(ctx, clientMovie)
 }
+
+// ListMovies adapts the client.RadarrClient.ListMovies method.
+func (a *RadarrClientAdapter) ListMovies(ctx context.Context) ([]Movie, error) {
+	clientMovies, err := a.client.ListMovies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientMovies(clientMovies), nil
+}
+
+// GetMovie adapts the client.RadarrClient.GetMovie method.
+func (a *RadarrClientAdapter) GetMovie(ctx context.Context, tmdbID int) (Movie, error) {
+	clientMovie, err := a.client.GetMovie(ctx, tmdbID)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	return fromClientMovie(clientMovie), nil
+}
+
+// Calendar adapts the client.RadarrClient.Calendar method.
+func (a *RadarrClientAdapter) Calendar(ctx context.Context, start, end time.Time) ([]Movie, error) {
+	clientMovies, err := a.client.Calendar(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientMovies(clientMovies), nil
+}
+
+// Queue adapts the client.RadarrClient.Queue method.
+func (a *RadarrClientAdapter) Queue(ctx context.Context) ([]QueueItem, error) {
+	clientQueue, err := a.client.Queue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientQueue("radarr", clientQueue), nil
+}
+
+// Tags adapts the client.RadarrClient.Tags method.
+func (a *RadarrClientAdapter) Tags(ctx context.Context) ([]Tag, error) {
+	clientTags, err := a.client.Tags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientTags(clientTags), nil
+}
+
+// CreateTag adapts the client.RadarrClient.CreateTag method.
+func (a *RadarrClientAdapter) CreateTag(ctx context.Context, label string) (Tag, error) {
+	tag, err := a.client.CreateTag(ctx, label)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	return Tag{ID: tag.ID, Label: tag.Label}, nil
+}
+
+// DiskSpace adapts the client.RadarrClient.DiskSpace method.
+func (a *RadarrClientAdapter) DiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	clientDisks, err := a.client.DiskSpace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientDiskSpace(clientDisks), nil
+}
+
+// EditMovies adapts the client.RadarrClient.EditMovies method.
+func (a *RadarrClientAdapter) EditMovies(ctx context.Context, libraryIDs []int, qualityProfileID int) error {
+	return a.client.EditMovies(ctx, libraryIDs, qualityProfileID)
+}
+
+// QualityProfiles adapts the client.RadarrClient.QualityProfiles method.
+func (a *RadarrClientAdapter) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	clientProfiles, err := a.client.QualityProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientQualityProfiles(clientProfiles), nil
+}
+
+// RootFolders adapts the client.RadarrClient.RootFolders method.
+func (a *RadarrClientAdapter) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	clientFolders, err := a.client.RootFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClientRootFolders(clientFolders), nil
+}
+
+// Collections adapts the client.RadarrClient.Collections method.
+func (a *RadarrClientAdapter) Collections(ctx context.Context, tmdbID int) ([]Collection, error) {
+	clientCollections, err := a.client.Collections(ctx, tmdbID)
+	if err != nil {
+		return nil, err
+	}
+
+	collections := make([]Collection, len(clientCollections))
+	for i, c := range clientCollections {
+		collections[i] = fromClientCollection(c)
+	}
+	return collections, nil
+}
+
+// UpdateCollection adapts the client.RadarrClient.UpdateCollection method.
+func (a *RadarrClientAdapter) UpdateCollection(ctx context.Context, collection Collection) error {
+	return a.client.UpdateCollection(ctx, toClientCollection(collection))
+}
+
+func fromClientSeries(s client.Series) Series {
+	return Series{
+		ID:            s.ID,
+		LibraryID:     s.LibraryID,
+		Title:         s.Title,
+		Year:          s.Year,
+		Status:        s.Status,
+		Overview:      s.Overview,
+		Genres:        s.Genres,
+		Certification: s.Certification,
+		ProfileID:     s.ProfileID,
+		Path:          s.Path,
+		Tags:          s.Tags,
+		SeasonCount:   seasonCount(s.Seasons),
+	}
+}
+
+// seasonCount returns the number of the last season, not counting specials.
+func seasonCount(seasons []client.Season) int {
+	count := 0
+	for _, season := range seasons {
+		count = max(count, season.SeasonNumber)
+	}
+	return count
+}
+
+func fromClientSeriesList(clientSeries []client.Series) []Series {
+	series := make([]Series, len(clientSeries))
+	for i, s := range clientSeries {
+		series[i] = fromClientSeries(s)
+	}
+	return series
+}
+
+func toClientSeries(s Series) client.Series {
+	return client.Series{
+		ID:            s.ID,
+		LibraryID:     s.LibraryID,
+		Title:         s.Title,
+		Year:          s.Year,
+		Status:        s.Status,
+		Overview:      s.Overview,
+		Genres:        s.Genres,
+		Certification: s.Certification,
+		ProfileID:     s.ProfileID,
+		Path:          s.Path,
+		Tags:          s.Tags,
+	}
+}
+
+func fromClientMovie(m client.Movie) Movie {
+	return Movie{
+		ID:              m.ID,
+		LibraryID:       m.LibraryID,
+		Title:           m.Title,
+		Year:            m.Year,
+		Status:          m.Status,
+		Overview:        m.Overview,
+		Genres:          m.Genres,
+		Certification:   m.Certification,
+		HasFile:         m.HasFile,
+		InCinemas:       m.InCinemas,
+		DigitalRelease:  m.DigitalRelease,
+		PhysicalRelease: m.PhysicalRelease,
+		ProfileID:       m.ProfileID,
+		Path:            m.Path,
+		Tags:            m.Tags,
+		Collection:      (*MovieCollection)(m.Collection),
+	}
+}
+
+func fromClientMovies(clientMovies []client.Movie) []Movie {
+	movies := make([]Movie, len(clientMovies))
+	for i, m := range clientMovies {
+		movies[i] = fromClientMovie(m)
+	}
+	return movies
+}
+
+func toClientMovie(m Movie) client.Movie {
+	return client.Movie{
+		ID:              m.ID,
+		LibraryID:       m.LibraryID,
+		Title:           m.Title,
+		Year:            m.Year,
+		Status:          m.Status,
+		Overview:        m.Overview,
+		Genres:          m.Genres,
+		Certification:   m.Certification,
+		HasFile:         m.HasFile,
+		InCinemas:       m.InCinemas,
+		DigitalRelease:  m.DigitalRelease,
+		PhysicalRelease: m.PhysicalRelease,
+		ProfileID:       m.ProfileID,
+		Path:            m.Path,
+		Tags:            m.Tags,
+		Collection:      (*client.MovieCollection)(m.Collection),
+	}
+}
+
+func fromClientCollection(c client.Collection) Collection {
+	movies := make([]CollectionMovie, len(c.Movies))
+	for i, m := range c.Movies {
+		movies[i] = CollectionMovie{
+			ID:        m.TmdbID,
+			Title:     m.Title,
+			Year:      m.Year,
+			Status:    m.Status,
+			InLibrary: m.IsExisting,
+		}
+	}
+	return Collection{
+		ID:                  c.ID,
+		Title:               c.Title,
+		TmdbID:              c.TmdbID,
+		Monitored:           c.Monitored,
+		QualityProfileID:    c.QualityProfileID,
+		RootFolderPath:      c.RootFolderPath,
+		MinimumAvailability: c.MinimumAvailability,
+		SearchOnAdd:         c.SearchOnAdd,
+		Movies:              movies,
+	}
+}
+
+func toClientCollection(c Collection) client.Collection {
+	movies := make([]client.CollectionMovie, len(c.Movies))
+	for i, m := range c.Movies {
+		movies[i] = client.CollectionMovie{
+			TmdbID:     m.ID,
+			Title:      m.Title,
+			Year:       m.Year,
+			Status:     m.Status,
+			IsExisting: m.InLibrary,
+		}
+	}
+	return client.Collection{
+		ID:                  c.ID,
+		Title:               c.Title,
+		TmdbID:              c.TmdbID,
+		Monitored:           c.Monitored,
+		QualityProfileID:    c.QualityProfileID,
+		RootFolderPath:      c.RootFolderPath,
+		MinimumAvailability: c.MinimumAvailability,
+		SearchOnAdd:         c.SearchOnAdd,
+		Movies:              movies,
+	}
+}
+
+func fromClientQueue(source string, clientQueue []client.QueueItem) []QueueItem {
+	queue := make([]QueueItem, len(clientQueue))
+	for i, q := range clientQueue {
+		libraryID := q.MovieID
+		if source == "sonarr" {
+			libraryID = q.SeriesID
+		}
+		queue[i] = QueueItem{
+			Source:                  source,
+			LibraryID:               libraryID,
+			Title:                   q.Title,
+			Status:                  q.Status,
+			TrackedDownloadState:    q.TrackedDownloadState,
+			Size:                    q.Size,
+			SizeLeft:                q.SizeLeft,
+			TimeLeft:                q.TimeLeft,
+			EstimatedCompletionTime: q.EstimatedCompletionTime,
+		}
+	}
+	return queue
+}
+
+func fromClientTags(clientTags []client.Tag) []Tag {
+	tags := make([]Tag, len(clientTags))
+	for i, t := range clientTags {
+		tags[i] = Tag{ID: t.ID, Label: t.Label}
+	}
+	return tags
+}
+
+func fromClientQualityProfiles(clientProfiles []client.QualityProfile) []QualityProfile {
+	profiles := make([]QualityProfile, len(clientProfiles))
+	for i, p := range clientProfiles {
+		profiles[i] = QualityProfile{
+			ID:      p.ID,
+			Name:    p.Name,
+			Allowed: p.AllowedQualities(),
+			Cutoff:  p.CutoffName(),
+		}
+	}
+	return profiles
+}
+
+func fromClientRootFolders(clientFolders []client.RootFolder) []RootFolder {
+	folders := make([]RootFolder, len(clientFolders))
+	for i, f := range clientFolders {
+		folders[i] = RootFolder{
+			ID:         f.ID,
+			Path:       f.Path,
+			Accessible: f.Accessible,
+			FreeSpace:  f.FreeSpace,
+		}
+	}
+	return folders
+}
+
+func fromClientDiskSpace(clientDisks []client.DiskSpace) []DiskSpace {
+	disks := make([]DiskSpace, len(clientDisks))
+	for i, d := range clientDisks {
+		disks[i] = DiskSpace{
+			Path:       d.Path,
+			Label:      d.Label,
+			FreeSpace:  d.FreeSpace,
+			TotalSpace: d.TotalSpace,
+		}
+	}
+	return disks
+}
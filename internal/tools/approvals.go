@@ -0,0 +1,306 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/approval"
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/events"
+)
+
+// ApprovalQueue is a simplified interface for the approval store.
+type ApprovalQueue interface {
+	Add(r approval.Request) (approval.Request, error)
+	Pending() ([]approval.Request, error)
+	Requests(user string) ([]approval.Request, error)
+	Begin(id int, decidedBy string) (approval.Request, error)
+	Release(id int) (approval.Request, error)
+	Decide(id int, status, decidedBy, reason string) (approval.Request, error)
+	Updates(user string) ([]approval.Request, error)
+}
+
+// WithApprovals holds download requests from every MCP client not in admins
+// in queue until an admin approves them.
+func WithApprovals(queue ApprovalQueue, admins []string) Option {
+	return func(m *MediaTools) {
+		m.approvals = queue
+		m.admins = admins
+	}
+}
+
+// isAdmin reports whether the MCP client making the request may skip the
+// approval queue and decide on queued requests.
+func (m *MediaTools) isAdmin(ctx context.Context) bool {
+	if m.approvals == nil {
+		return true
+	}
+	user := audit.User(ctx)
+	return slices.ContainsFunc(m.admins, func(admin string) bool {
+		return strings.EqualFold(admin, user)
+	})
+}
+
+// holdDownload queues a download request for approval.
+func (m *MediaTools) holdDownload(ctx context.Context, mediaType, mediaName string, mediaID int, quality string) *mcp.CallToolResult {
+	if mediaType != "movie" && mediaType != "series" {
+		return mcp.NewToolResultText(fmt.Sprintf("Unsupported media type: %s. Must be 'movie' or 'series'.", mediaType))
+	}
+
+	request, err := m.approvals.Add(approval.Request{
+		User:    audit.User(ctx),
+		Type:    mediaType,
+		Title:   mediaName,
+		MediaID: mediaID,
+		Quality: quality,
+	})
+	if err != nil {
+		m.logger.Printf("Error queueing download request: %v", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to queue the request for approval: %v", err))
+	}
+
+	audit.Describe(ctx, instanceFor(mediaType), mediaName, mediaID)
+	audit.Hold(ctx)
+	m.logger.Printf("Queued request %d from %s for %s: %s", request.ID, request.User, mediaType, mediaName)
+	m.notifyRequest(request, events.TypeRequestPending,
+		fmt.Sprintf("Request #%d from %s for %s is waiting for approval", request.ID, request.User, mediaName))
+
+	return mcp.NewToolResultText(fmt.Sprintf(
+		"Request #%d for %s is waiting for an admin to approve it. Tell the user it will be downloaded once approved, "+
+			"and that my_quota and recent_events will say when it is decided.",
+		request.ID, mediaName))
+}
+
+// notifyRequest reports a change to a queued request in the event log, which
+// pushes it to connected clients.
+func (m *MediaTools) notifyRequest(r approval.Request, eventType, message string) {
+	if m.events == nil {
+		return
+	}
+	m.events.Add(events.Event{
+		Source:  "mcparr",
+		Type:    eventType,
+		Title:   r.Title,
+		MediaID: r.MediaID,
+		Message: message,
+	})
+}
+
+// decisionMessage describes the approval or denial of a queued request.
+func decisionMessage(r approval.Request) string {
+	message := fmt.Sprintf("Request #%d from %s for %s was %s by %s", r.ID, r.User, r.Title, r.Status, r.DecidedBy)
+	if r.Reason != "" {
+		message += ": " + r.Reason
+	}
+	return message
+}
+
+// requestUpdates returns the decisions on the caller's queued requests that
+// it has not been told of yet. Admins often run in another process than the
+// requester, so the decisions are read from the approval store rather than
+// the event log.
+func (m *MediaTools) requestUpdates(ctx context.Context) string {
+	if m.approvals == nil || m.isAdmin(ctx) {
+		return ""
+	}
+
+	updates, err := m.approvals.Updates(audit.User(ctx))
+	if err != nil {
+		m.logger.Printf("Error reading updates to queued requests: %v", err)
+		return ""
+	}
+	if len(updates) == 0 {
+		return ""
+	}
+
+	var resultBuilder strings.Builder
+	resultBuilder.WriteString("Updates to your requests:\n")
+	for _, r := range updates {
+		resultBuilder.WriteString(fmt.Sprintf("- [%s] %s\n", r.DecidedAt.Local().Format("2006-01-02 15:04"), decisionMessage(r)))
+	}
+	return resultBuilder.String()
+}
+
+// requireAdmin returns an error result unless the approval queue is enabled
+// and the caller is an admin.
+func (m *MediaTools) requireAdmin(ctx context.Context) *mcp.CallToolResult {
+	if m.approvals == nil {
+		return mcp.NewToolResultText("Approval queue is not enabled; download requests are carried out immediately.")
+	}
+	if !m.isAdmin(ctx) {
+		return mcp.NewToolResultError("Only admin clients can manage download requests. Do not retry; tell the user an admin has to do this.")
+	}
+	return nil
+}
+
+// ListPendingRequests returns a tool for listing requests waiting for
+// approval.
+func (m *MediaTools) ListPendingRequests() server.ServerTool {
+	tool := mcp.NewTool(
+		"list_pending_requests",
+		mcp.WithDescription("List download requests from other users that are waiting for approval"),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if result := m.requireAdmin(ctx); result != nil {
+			return result, nil
+		}
+
+		pending, err := m.approvals.Pending()
+		if err != nil {
+			m.logger.Printf("Error listing pending requests: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read the approval queue: %v", err)), nil
+		}
+		if len(pending) == 0 {
+			return mcp.NewToolResultText("No requests are waiting for approval."), nil
+		}
+
+		var resultBuilder strings.Builder
+		resultBuilder.WriteString(fmt.Sprintf("Found %d pending requests:\n", len(pending)))
+		for _, r := range pending {
+			resultBuilder.WriteString(fmt.Sprintf("#%d. [%s] %s requested %s %s (ID: %d)",
+				r.ID, r.Time.Local().Format("2006-01-02 15:04"), r.User, r.Type, r.Title, r.MediaID))
+			if r.Quality != "" {
+				resultBuilder.WriteString(" in " + r.Quality)
+			}
+			resultBuilder.WriteString("\n")
+		}
+		resultBuilder.WriteString("Use approve_request or deny_request with the request number.")
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+// ApproveRequest returns a tool for approving a queued download request.
+func (m *MediaTools) ApproveRequest() server.ServerTool {
+	tool := mcp.NewTool(
+		"approve_request",
+		mcp.WithDescription("Approve a pending download request and download the media"),
+		mcp.WithNumber(
+			"request_id",
+			mcp.Required(),
+			mcp.Description("The number of the request, from list_pending_requests"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if result := m.requireAdmin(ctx); result != nil {
+			return result, nil
+		}
+
+		requestID, err := request.RequireInt("request_id")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid request ID: %v", err)), nil
+		}
+
+		// Mark the request as being approved before adding it, so a second
+		// approval of it fails instead of adding it again.
+		approver := audit.User(ctx)
+		pending, err := m.approvals.Begin(requestID, approver)
+		if err != nil {
+			return requestError(requestID, err), nil
+		}
+
+		result := m.download(ctx, pending.Type, pending.Title, pending.MediaID, pending.User, pending.Quality)
+		if result.IsError {
+			if _, err := m.approvals.Release(requestID); err != nil {
+				m.logger.Printf("Error returning request %d to the queue: %v", requestID, err)
+			}
+			return result, nil
+		}
+
+		approved, err := m.approvals.Decide(requestID, approval.StatusApproved, approver, "")
+		if err != nil {
+			m.logger.Printf("Error approving request %d: %v", requestID, err)
+			return requestError(requestID, err), nil
+		}
+
+		m.logger.Printf("Request %d approved by %s", requestID, approver)
+		m.notifyRequest(approved, events.TypeRequestApproved, decisionMessage(approved))
+
+		return mcp.NewToolResultText(fmt.Sprintf("Approved request #%d from %s. %s",
+			approved.ID, approved.User, result.Content[0].(mcp.TextContent).Text)), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+// DenyRequest returns a tool for denying a queued download request.
+func (m *MediaTools) DenyRequest() server.ServerTool {
+	tool := mcp.NewTool(
+		"deny_request",
+		mcp.WithDescription("Deny a pending download request"),
+		mcp.WithNumber(
+			"request_id",
+			mcp.Required(),
+			mcp.Description("The number of the request, from list_pending_requests"),
+		),
+		mcp.WithString(
+			"reason",
+			mcp.Description("Why the request was denied, passed on to the requester (optional)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if result := m.requireAdmin(ctx); result != nil {
+			return result, nil
+		}
+
+		requestID, err := request.RequireInt("request_id")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid request ID: %v", err)), nil
+		}
+		reason := request.GetString("reason", "")
+
+		denier := audit.User(ctx)
+		denied, err := m.approvals.Decide(requestID, approval.StatusDenied, denier, reason)
+		if err != nil {
+			return requestError(requestID, err), nil
+		}
+		audit.Describe(ctx, instanceFor(denied.Type), denied.Title, denied.MediaID)
+
+		m.logger.Printf("Request %d denied by %s", requestID, denier)
+		m.notifyRequest(denied, events.TypeRequestDenied, decisionMessage(denied))
+
+		return mcp.NewToolResultText(fmt.Sprintf("Denied request #%d from %s for %s.", denied.ID, denied.User, denied.Title)), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+// instanceFor returns the instance that manages the media type.
+func instanceFor(mediaType string) string {
+	if mediaType == "series" {
+		return "Sonarr"
+	}
+	return "Radarr"
+}
+
+// requestError explains why a queued request can't be decided.
+func requestError(requestID int, err error) *mcp.CallToolResult {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return mcp.NewToolResultError(fmt.Sprintf("There is no request #%d. Check the number with list_pending_requests.", requestID))
+	case errors.Is(err, approval.ErrDecided):
+		return mcp.NewToolResultError(fmt.Sprintf("Request #%d was already approved or denied, or is being approved.", requestID))
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to update request #%d: %v", requestID, err))
+	}
+}
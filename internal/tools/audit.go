@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+)
+
+// AuditedTools maps the tools that change or search the library to the audit
+// action they perform.
+var AuditedTools = map[string]string{
+	"search":                 audit.ActionSearch,
+	"search_media_id":        audit.ActionSearch,
+	"search_by_genre":        audit.ActionSearch,
+	"request_download":       audit.ActionAdd,
+	"request_download_batch": audit.ActionAdd,
+	"collection":             audit.ActionAdd,
+	"set_quality_profile":    audit.ActionEdit,
+	"request_delete":         audit.ActionDelete,
+	"approve_request":        audit.ActionAdd,
+	"deny_request":           audit.ActionEdit,
+}
+
+// changingArguments lists the audited tools that only change the library
+// when one of these boolean arguments is set, and otherwise just list.
+var changingArguments = map[string][]string{
+	"collection": {"add_missing", "monitor"},
+}
+
+// Mutates reports whether a tool call changes the library: its tool is
+// audited with an action other than search and, for tools that can also just
+// list, it sets one of the arguments that make changes.
+func Mutates(request mcp.CallToolRequest) bool {
+	action, audited := AuditedTools[request.Params.Name]
+	if !audited || action == audit.ActionSearch {
+		return false
+	}
+	arguments, ok := changingArguments[request.Params.Name]
+	if !ok {
+		return true
+	}
+	for _, name := range arguments {
+		if request.GetBool(name, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditTrail is a simplified interface for the audit log.
+type AuditTrail interface {
+	Query(filter audit.Filter) ([]audit.Entry, error)
+}
+
+// WithAuditTrail sets the audit log backing the audit_log tool.
+func WithAuditTrail(trail AuditTrail) Option {
+	return func(m *MediaTools) {
+		m.auditTrail = trail
+	}
+}
+
+// AuditLog returns a tool for querying the audit log.
+func (m *MediaTools) AuditLog() server.ServerTool {
+	tool := mcp.NewTool(
+		"audit_log",
+		mcp.WithDescription("List the actions that changed or searched the library, such as adds, deletes and searches, with who made them and whether they succeeded. When download requests need approval, only admin clients see the actions of other clients"),
+		mcp.WithString(
+			"since",
+			mcp.Description("Only return actions on or after this date, YYYY-MM-DD (optional)"),
+		),
+		mcp.WithString(
+			"until",
+			mcp.Description("Only return actions before this date, YYYY-MM-DD (optional)"),
+		),
+		mcp.WithString(
+			"user",
+			mcp.Description("Only return actions made by this MCP client (optional)"),
+		),
+		mcp.WithString(
+			"action",
+			mcp.Description("Only return actions of this kind (optional)"),
+			mcp.Enum(audit.ActionAdd, audit.ActionDelete, audit.ActionSearch, audit.ActionEdit),
+		),
+		mcp.WithNumber(
+			"limit",
+			mcp.Description("Maximum number of actions to return (default: 20)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.auditTrail == nil {
+			return mcp.NewToolResultText("Audit log is not enabled."), nil
+		}
+
+		filter := audit.Filter{
+			User:   request.GetString("user", ""),
+			Action: request.GetString("action", ""),
+			Limit:  request.GetInt("limit", 20),
+		}
+
+		// With the approval queue on, what other clients asked for is only
+		// for admins to see.
+		if !m.isAdmin(ctx) {
+			user := audit.User(ctx)
+			if filter.User != "" && !strings.EqualFold(filter.User, user) {
+				return mcp.NewToolResultError("Only admin clients can see the actions of other clients. Do not retry; leave out the user to list your own actions."), nil
+			}
+			filter.User = user
+		}
+
+		var err error
+		if filter.Since, err = ParseDate(request.GetString("since", "")); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid since date: %v", err)), nil
+		}
+		if filter.Until, err = ParseDate(request.GetString("until", "")); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid until date: %v", err)), nil
+		}
+
+		m.logger.Printf("Querying audit log: %+v", filter)
+
+		entries, err := m.auditTrail.Query(filter)
+		if err != nil {
+			m.logger.Printf("Error querying audit log: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read the audit log: %v", err)), nil
+		}
+
+		if len(entries) == 0 {
+			return mcp.NewToolResultText("No matching actions in the audit log."), nil
+		}
+
+		var resultBuilder strings.Builder
+		resultBuilder.WriteString(fmt.Sprintf("Found %d actions:\n", len(entries)))
+		for i, e := range entries {
+			resultBuilder.WriteString(fmt.Sprintf("%d. %s\n", i+1, FormatAuditEntry(e)))
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+// FormatAuditEntry returns a one-line summary of an audit entry.
+func FormatAuditEntry(e audit.Entry) string {
+	subject := e.Title
+	for _, arg := range []string{"name", "genre"} {
+		if value, ok := e.Arguments[arg].(string); ok && subject == "" {
+			subject = value
+		}
+	}
+	if e.MediaID != 0 {
+		subject = fmt.Sprintf("%s (ID: %d)", subject, e.MediaID)
+	}
+	if e.Instance != "" {
+		subject = fmt.Sprintf("%s in %s", subject, e.Instance)
+	}
+
+	line := fmt.Sprintf("[%s] %s %s %s: %s", e.Time.Local().Format("2006-01-02 15:04"), e.User, e.Action, subject, e.Outcome)
+	if e.Error != "" {
+		line += " (" + e.Error + ")"
+	}
+	return line
+}
+
+// ParseDate parses a YYYY-MM-DD date or an RFC 3339 timestamp. An empty
+// string is the zero time.
+func ParseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
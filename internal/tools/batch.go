@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/toolresult"
+	"github.com/IdoKendo/mcparr/pkg/client"
+)
+
+const (
+	// maxBatchItems is the most titles request_download_batch takes at once.
+	maxBatchItems = 50
+	// batchConcurrency is how many titles are looked up at a time.
+	batchConcurrency = 4
+	// maxCandidates is how many candidates are listed for an ambiguous title.
+	maxCandidates = 3
+)
+
+// Outcomes of an item of a batch request.
+const (
+	batchAdded     = "added"
+	batchPending   = "waiting for approval"
+	batchPresent   = "already in the library"
+	batchAmbiguous = "ambiguous"
+	batchNotFound  = "not found"
+	batchFailed    = "failed"
+)
+
+// candidate is a lookup result of either media type.
+type candidate struct {
+	Title     string
+	Year      int
+	ID        int
+	InLibrary bool
+}
+
+func (c candidate) String() string {
+	return fmt.Sprintf("%s (%d) (ID: %d)", c.Title, c.Year, c.ID)
+}
+
+// lookupCandidates looks query up in Sonarr or Radarr and returns the results
+// the caller's content rules allow, and the query as it was read. The query
+// is parsed with ParseQuery: its title is looked up and the results are
+// filtered by its year, country and season. A country is only kept if a
+// result is the title from that country, as the word may end the title, as
+// in "This Is Us". If no result fits, the query is looked up again without
+// the filters, in case the hints belong to the title.
+func (m *MediaTools) lookupCandidates(ctx context.Context, mediaType, query string) ([]candidate, Query, error) {
+	q := ParseQuery(query)
+	candidates, err := m.lookupQuery(ctx, mediaType, q.Title, q)
+	if err != nil {
+		return nil, q, err
+	}
+	if q.Country != "" && !slices.ContainsFunc(candidates, func(c candidate) bool { return q.fromCountry(c.Title) }) {
+		q = q.withoutCountry()
+		if candidates, err = m.lookupQuery(ctx, mediaType, q.Title, q); err != nil {
+			return nil, q, err
+		}
+	}
+	if len(candidates) > 0 || !q.Filtered() {
+		return candidates, q, nil
+	}
+
+	q = Query{Title: q.Text, Text: q.Text, Quality: q.Quality}
+	candidates, err = m.lookupQuery(ctx, mediaType, q.Title, q)
+	return candidates, q, err
+}
+
+// lookupQuery looks title up in Sonarr or Radarr and returns the allowed
+// results that match q.
+func (m *MediaTools) lookupQuery(ctx context.Context, mediaType, title string, q Query) ([]candidate, error) {
+	var candidates []candidate
+	switch mediaType {
+	case "series":
+		series, err := m.sonarrClient.LookupSeries(ctx, title)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range allowedSeries(m.contentRules(ctx), series) {
+			if q.matches(s.Title, s.Year, s.SeasonCount) {
+				candidates = append(candidates, candidate{Title: s.Title, Year: s.Year, ID: s.ID, InLibrary: s.LibraryID > 0})
+			}
+		}
+	case "movie":
+		movies, err := m.radarrClient.LookupMovie(ctx, title)
+		if err != nil {
+			return nil, err
+		}
+		for _, movie := range allowedMovies(m.contentRules(ctx), movies) {
+			if q.matches(movie.Title, movie.Year, 0) {
+				candidates = append(candidates, candidate{Title: movie.Title, Year: movie.Year, ID: movie.ID, InLibrary: movie.LibraryID > 0})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// normalizeTitle lowercases title and drops everything but letters and
+// digits, so "Mission: Impossible" matches "mission impossible".
+func normalizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, title)
+}
+
+// pickCandidate chooses the lookup result meant by query: the first result
+// for an external ID, the only result whose title matches the query or its
+// parsed title, or the only result. Otherwise it returns the candidates to
+// choose from.
+func pickCandidate(query string, candidates []candidate) (candidate, []candidate) {
+	if len(candidates) == 0 {
+		return candidate{}, nil
+	}
+	if _, exact := client.ParseExternalID(query); exact || len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	q := ParseQuery(query)
+	var matches []candidate
+	for _, c := range candidates {
+		title := normalizeTitle(c.Title)
+		if title == normalizeTitle(query) || title == normalizeTitle(q.Title) || title == normalizeTitle(q.Text) {
+			matches = append(matches, c)
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		return candidate{}, matches[:min(len(matches), maxCandidates)]
+	default:
+		return candidate{}, candidates[:min(len(candidates), maxCandidates)]
+	}
+}
+
+// batchItem is one title of a batch request and what happened to it.
+type batchItem struct {
+	query string
+	// parsed is the query as it was read.
+	parsed     Query
+	mediaType  string
+	quality    string
+	match      candidate
+	candidates []candidate
+	status     string
+	detail     string
+}
+
+// RequestDownloadBatch returns a tool for requesting many downloads at once.
+func (m *MediaTools) RequestDownloadBatch() server.ServerTool {
+	tool := mcp.NewTool(
+		"request_download_batch",
+		mcp.WithDescription(fmt.Sprintf(
+			"Request downloads for many movies and TV shows at once, by name or IMDb, TMDb or TVDb ID, "+
+				"and report what happened to each. Use this instead of many request_download calls. At most %d titles. "+
+				"Titles may carry a year, season and quality, as in \"Dune 2021 in 4K\" or \"the office us s3\".",
+			maxBatchItems)),
+		mcp.WithArray(
+			"movies",
+			mcp.Description("Names or IDs of movies to download"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray(
+			"series",
+			mcp.Description("Names or IDs of TV shows to download"),
+			mcp.WithStringItems(),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var items []*batchItem
+		for _, query := range request.GetStringSlice("movies", nil) {
+			items = append(items, &batchItem{query: query, mediaType: "movie"})
+		}
+		for _, query := range request.GetStringSlice("series", nil) {
+			items = append(items, &batchItem{query: query, mediaType: "series"})
+		}
+
+		switch {
+		case len(items) == 0:
+			return mcp.NewToolResultError("No titles given. Pass the names or IDs in movies and series."), nil
+		case len(items) > maxBatchItems:
+			return mcp.NewToolResultError(fmt.Sprintf("Too many titles: %d, the limit is %d. Split them into several calls.", len(items), maxBatchItems)), nil
+		}
+
+		m.logger.Printf("Requesting batch download of %d titles", len(items))
+
+		// Look the titles up concurrently; lookup errors are reported per
+		// item, so the group never fails.
+		var group errgroup.Group
+		group.SetLimit(batchConcurrency)
+		for _, item := range items {
+			group.Go(func() error {
+				m.resolveBatchItem(ctx, item)
+				return nil
+			})
+		}
+		group.Wait()
+
+		// Add the resolved titles one at a time, so each sees the quota used
+		// by the ones before it.
+		for _, item := range items {
+			if item.status != "" {
+				continue
+			}
+			if ctx.Err() != nil {
+				item.status, item.detail = batchFailed, "cancelled"
+				continue
+			}
+
+			itemCtx, done := audit.Item(ctx, audit.ActionAdd, map[string]any{"type": item.mediaType, "name": item.match.Title, "id": item.match.ID})
+			result := m.requestMedia(itemCtx, item.mediaType, item.match.Title, item.match.ID, item.quality)
+			done(result)
+
+			switch {
+			case result.IsError:
+				item.status, item.detail = batchFailed, toolresult.Text(result)
+			case m.approvals != nil && !m.isAdmin(ctx):
+				item.status = batchPending
+			default:
+				item.status = batchAdded
+			}
+		}
+
+		return mcp.NewToolResultText(formatBatchReport(items)), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+// resolveBatchItem looks up the title of item and picks the match to add,
+// or sets the status of the item if there is nothing to add.
+func (m *MediaTools) resolveBatchItem(ctx context.Context, item *batchItem) {
+	itemCtx, done := audit.Item(ctx, audit.ActionSearch, map[string]any{"type": item.mediaType, "name": item.query})
+	candidates, parsed, err := m.lookupCandidates(ctx, item.mediaType, item.query)
+	item.parsed = parsed
+	if err != nil {
+		m.logger.Printf("Error looking up %s %q: %v", item.mediaType, item.query, err)
+		audit.Fail(itemCtx, err)
+		done(toolError("Failed to look up "+item.query, err))
+		item.status, item.detail = batchFailed, describeError(err)
+		return
+	}
+	done(mcp.NewToolResultText(fmt.Sprintf("Found %d results", len(candidates))))
+
+	item.quality = parsed.Quality
+	match, ambiguous := pickCandidate(item.query, candidates)
+	switch {
+	case len(candidates) == 0:
+		item.status = batchNotFound
+	case len(ambiguous) > 0:
+		item.status, item.candidates = batchAmbiguous, ambiguous
+	case match.InLibrary:
+		item.status, item.match = batchPresent, match
+	default:
+		item.match = match
+	}
+}
+
+// formatBatchReport lists what happened to each item of a batch request.
+func formatBatchReport(items []*batchItem) string {
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.status]++
+	}
+
+	var b strings.Builder
+	var summary []string
+	for _, status := range []string{batchAdded, batchPending, batchPresent, batchAmbiguous, batchNotFound, batchFailed} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	fmt.Fprintf(&b, "Processed %d titles: %s.\n", len(items), strings.Join(summary, ", "))
+
+	for i, item := range items {
+		fmt.Fprintf(&b, "%d. %s %q%s: %s", i+1, item.mediaType, item.query, item.parsed.describe(), item.status)
+		switch item.status {
+		case batchAdded, batchPending, batchPresent:
+			fmt.Fprintf(&b, " - %s", item.match)
+		case batchAmbiguous:
+			names := make([]string, len(item.candidates))
+			for j, c := range item.candidates {
+				names[j] = c.String()
+			}
+			fmt.Fprintf(&b, " - did you mean %s?", strings.Join(names, ", or "))
+		case batchFailed:
+			fmt.Fprintf(&b, " - %s", item.detail)
+		}
+		b.WriteString("\n")
+	}
+
+	if counts[batchAmbiguous] > 0 {
+		b.WriteString("Ask the user which of the candidates they meant, then add it with request_download and its ID.\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/toolresult"
+)
+
+// States of a movie of a collection.
+const (
+	collectionOwned      = "owned"
+	collectionMissing    = "missing"
+	collectionUnreleased = "unreleased"
+)
+
+// collectionState returns whether a movie of a collection is in the library,
+// missing from it, or not released yet.
+func collectionState(movie CollectionMovie) string {
+	switch {
+	case movie.InLibrary:
+		return collectionOwned
+	case movie.Status == "announced" || movie.Status == "tba":
+		return collectionUnreleased
+	default:
+		return collectionMissing
+	}
+}
+
+// sameCollection reports whether name refers to the collection title,
+// ignoring case, punctuation and a trailing "Collection".
+func sameCollection(name, title string) bool {
+	name = strings.TrimSuffix(normalizeTitle(name), "collection")
+	title = strings.TrimSuffix(normalizeTitle(title), "collection")
+	return name != "" && name == title
+}
+
+// CollectionTool returns a tool for listing a movie collection and adding
+// its missing movies.
+func (m *MediaTools) CollectionTool() server.ServerTool {
+	tool := mcp.NewTool(
+		"collection",
+		mcp.WithDescription("Show a movie collection, such as all the Dune or Harry Potter movies, "+
+			"and which of its movies are owned, missing or unreleased. Optionally add the missing ones "+
+			"and monitor the collection so Radarr adds its future movies."),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the collection or of any movie in it"),
+		),
+		mcp.WithBoolean(
+			"add_missing",
+			mcp.Description("Add the released movies that are missing from the library (default: false)"),
+		),
+		mcp.WithBoolean(
+			"monitor",
+			mcp.Description("Monitor the collection so Radarr adds its new movies automatically (default: false)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			m.logger.Printf("Error getting collection name: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid name: %v", err)), nil
+		}
+		addMissing := request.GetBool("add_missing", false)
+		monitor := request.GetBool("monitor", false)
+
+		// Only the adds and the monitoring change the library; they are
+		// recorded as items of their own.
+		audit.Skip(ctx)
+
+		m.logger.Printf("Looking up collection: %s", name)
+
+		collection, missing, errResult := m.findCollection(ctx, name)
+		if errResult != nil {
+			return errResult, nil
+		}
+		if missing != "" {
+			return mcp.NewToolResultText(missing), nil
+		}
+
+		var hidden int
+		collection.Movies, hidden = m.allowedCollectionMovies(ctx, collection.Movies)
+
+		states := make([]string, len(collection.Movies))
+		details := make([]string, len(collection.Movies))
+		counts := make(map[string]int)
+		for i, movie := range collection.Movies {
+			states[i] = collectionState(movie)
+			counts[states[i]]++
+		}
+
+		if addMissing {
+			for i, movie := range collection.Movies {
+				if states[i] != collectionMissing {
+					continue
+				}
+				if ctx.Err() != nil {
+					details[i] = batchFailed + ": cancelled"
+					continue
+				}
+
+				itemCtx, done := audit.Item(ctx, audit.ActionAdd, map[string]any{"type": "movie", "name": movie.Title, "id": movie.ID})
+				result := m.requestMedia(itemCtx, "movie", movie.Title, movie.ID, "")
+				done(result)
+
+				switch {
+				case result.IsError:
+					details[i] = batchFailed + ": " + toolresult.Text(result)
+				case m.approvals != nil && !m.isAdmin(ctx):
+					details[i] = batchPending
+				default:
+					details[i] = batchAdded
+				}
+			}
+		}
+
+		var monitorNote string
+		if monitor {
+			monitorNote = m.monitorCollection(ctx, &collection)
+		}
+
+		var resultBuilder strings.Builder
+		monitored := "not monitored"
+		if collection.Monitored {
+			monitored = "monitored"
+		}
+		resultBuilder.WriteString(fmt.Sprintf("%s (TMDb ID: %d), %s\n", collection.Title, collection.TmdbID, monitored))
+		resultBuilder.WriteString(fmt.Sprintf("%d movies: %d %s, %d %s, %d %s.\n", len(collection.Movies),
+			counts[collectionOwned], collectionOwned, counts[collectionMissing], collectionMissing,
+			counts[collectionUnreleased], collectionUnreleased))
+		for i, movie := range collection.Movies {
+			resultBuilder.WriteString(fmt.Sprintf("%d. %s (%d) (ID: %d): %s", i+1, movie.Title, movie.Year, movie.ID, states[i]))
+			if details[i] != "" {
+				resultBuilder.WriteString(" - " + details[i])
+			}
+			resultBuilder.WriteString("\n")
+		}
+		if hidden > 0 {
+			resultBuilder.WriteString(fmt.Sprintf("%d more movies are not allowed by the content rules.\n", hidden))
+		}
+		if monitorNote != "" {
+			resultBuilder.WriteString(monitorNote + "\n")
+		}
+		if counts[collectionMissing] > 0 && !addMissing {
+			resultBuilder.WriteString("Call collection again with add_missing to add the missing movies.\n")
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+// findCollection returns the Radarr collection named name, or the collection
+// of the movie named name. If there is none, missing explains why.
+func (m *MediaTools) findCollection(ctx context.Context, name string) (collection Collection, missing string, errResult *mcp.CallToolResult) {
+	collections, err := m.radarrClient.Collections(ctx, 0)
+	if err != nil {
+		m.logger.Printf("Error getting collections: %v", err)
+		return Collection{}, "", toolError("Failed to fetch collections from Radarr", err)
+	}
+	for _, c := range collections {
+		if sameCollection(name, c.Title) {
+			return c, "", nil
+		}
+	}
+
+	movies, err := m.radarrClient.LookupMovie(ctx, name)
+	if err != nil {
+		m.logger.Printf("Error looking up movie: %v", err)
+		return Collection{}, "", toolError("Failed to fetch data from Radarr", err)
+	}
+	movies = allowedMovies(m.contentRules(ctx), movies)
+	if len(movies) == 0 {
+		return Collection{}, fmt.Sprintf("No collection or movie found for '%s'.", name), nil
+	}
+
+	movie := movies[0]
+	if movie.Collection == nil {
+		return Collection{}, fmt.Sprintf("%s (%d) is not part of a collection.", movie.Title, movie.Year), nil
+	}
+	for _, c := range collections {
+		if c.TmdbID == movie.Collection.TmdbID {
+			return c, "", nil
+		}
+	}
+
+	return Collection{}, fmt.Sprintf(
+		"%s (%d) belongs to the %s, which Radarr does not track yet. Radarr tracks a collection once one of its movies is in the library; "+
+			"request %s with request_download first, then try again.",
+		movie.Title, movie.Year, movie.Collection.Title, movie.Title), nil
+}
+
+// monitorCollection turns on monitoring of collection, with the default
+// quality profile and root folder if it has none, and returns what happened.
+func (m *MediaTools) monitorCollection(ctx context.Context, collection *Collection) string {
+	if collection.Monitored {
+		return "The collection is already monitored."
+	}
+	if !m.isAdmin(ctx) {
+		return "Only admin clients can monitor collections, so it was left unmonitored."
+	}
+
+	updated := *collection
+	updated.Monitored = true
+	if updated.QualityProfileID == 0 {
+		updated.QualityProfileID = m.config.DefaultQualityProfileID()
+	}
+	if updated.RootFolderPath == "" {
+		updated.RootFolderPath = m.config.MoviesRootPath()
+	}
+
+	itemCtx, done := audit.Item(ctx, audit.ActionEdit, map[string]any{"collection": collection.Title, "monitor": true})
+	audit.Describe(itemCtx, "Radarr", collection.Title, collection.TmdbID)
+
+	var result *mcp.CallToolResult
+	if err := m.radarrClient.UpdateCollection(ctx, updated); err != nil {
+		m.logger.Printf("Error monitoring collection %s: %v", collection.Title, err)
+		audit.Fail(itemCtx, err)
+		result = toolError("Failed to monitor the collection", err)
+	} else {
+		m.logger.Printf("Monitoring collection %s", collection.Title)
+		*collection = updated
+		result = mcp.NewToolResultText("Radarr now monitors the collection and will add its new movies.")
+	}
+	done(result)
+
+	return toolresult.Text(result)
+}
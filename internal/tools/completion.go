@@ -0,0 +1,466 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/policy"
+)
+
+// maxCompletionValues is the most values a completion response may carry.
+const maxCompletionValues = 100
+
+// libraryEntry is a title in the completion index, with what content rules
+// check.
+type libraryEntry struct {
+	ID            int
+	Title         string
+	Certification string
+	Genres        []string
+	Overview      string
+}
+
+// instanceMetadata holds the names defined in a single arr instance.
+type instanceMetadata struct {
+	tags        map[string]int
+	profiles    map[string]int
+	rootFolders []string
+}
+
+// metadataSource is implemented by both SonarrClient and RadarrClient.
+type metadataSource interface {
+	Tags(ctx context.Context) ([]Tag, error)
+	QualityProfiles(ctx context.Context) ([]QualityProfile, error)
+	RootFolders(ctx context.Context) ([]RootFolder, error)
+	DiskSpace(ctx context.Context) ([]DiskSpace, error)
+}
+
+// Backoff after failed refreshes of an instance: it doubles from
+// minRefreshBackoff with every failure, up to maxRefreshBackoff.
+const (
+	minRefreshBackoff = 5 * time.Second
+	maxRefreshBackoff = 5 * time.Minute
+)
+
+// refreshTimeout bounds a refresh running in the background.
+const refreshTimeout = 30 * time.Second
+
+// instanceIndex holds the completion values of a single arr instance.
+type instanceIndex struct {
+	loaded    bool
+	refreshed time.Time
+	// failures counts the refreshes that failed in a row, and retryAt is
+	// when the next one may start.
+	failures int
+	retryAt  time.Time
+
+	entries  []libraryEntry
+	genres   []string
+	metadata instanceMetadata
+}
+
+// CompletionIndex caches the library values used to complete prompt and
+// resource template arguments: titles, genres, tags, quality profiles and
+// root folders. Sonarr and Radarr are refreshed separately, in the
+// background, when their values get older than the refresh interval; the
+// old values are served until then, and an instance that fails to refresh
+// is retried with backoff.
+type CompletionIndex struct {
+	sonarrClient SonarrClient
+	radarrClient RadarrClient
+	interval     time.Duration
+	logger       *log.Logger
+	group        singleflight.Group
+	contentFor   func(client string) policy.Content
+
+	mu        sync.RWMutex
+	instances map[string]*instanceIndex
+}
+
+// NewCompletionIndex creates a new CompletionIndex refreshed every interval.
+func NewCompletionIndex(sonarrClient SonarrClient, radarrClient RadarrClient, interval time.Duration) *CompletionIndex {
+	return &CompletionIndex{
+		sonarrClient: sonarrClient,
+		radarrClient: radarrClient,
+		interval:     interval,
+		logger:       log.Default(),
+		instances: map[string]*instanceIndex{
+			"sonarr": {},
+			"radarr": {},
+		},
+	}
+}
+
+// WithCompletionIndex sets the index used to resolve tag, quality profile
+// and root folder names in prompts.
+func WithCompletionIndex(index *CompletionIndex) Option {
+	return func(m *MediaTools) {
+		m.index = index
+	}
+}
+
+// SetContentRules hides the titles and genres the content rules of each MCP
+// client, as returned by contentFor, don't allow from its completions.
+func (c *CompletionIndex) SetContentRules(contentFor func(client string) policy.Content) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contentFor = contentFor
+}
+
+// allowed returns the entries and genres the content rules of the MCP client
+// making the request allow. c.mu must be held.
+func (c *CompletionIndex) allowed(ctx context.Context, entries []libraryEntry, genres []string) ([]libraryEntry, []string) {
+	if c.contentFor == nil {
+		return entries, genres
+	}
+	rules := c.contentFor(audit.User(ctx))
+	if rules.Empty() {
+		return entries, genres
+	}
+
+	var allowedEntries []libraryEntry
+	for _, e := range entries {
+		if rules.Check(e.Certification, e.Genres, e.Title, e.Overview) == "" {
+			allowedEntries = append(allowedEntries, e)
+		}
+	}
+	var allowedGenres []string
+	for _, g := range genres {
+		if !slices.ContainsFunc(rules.BlockedGenres, func(blocked string) bool { return strings.EqualFold(blocked, g) }) {
+			allowedGenres = append(allowedGenres, g)
+		}
+	}
+	return allowedEntries, allowedGenres
+}
+
+// Refresh rebuilds the index from Sonarr and Radarr. An instance that fails
+// keeps its old values and does not hold back the other.
+func (c *CompletionIndex) Refresh(ctx context.Context) error {
+	return errors.Join(c.refresh(ctx, "sonarr"), c.refresh(ctx, "radarr"))
+}
+
+// refresh rebuilds the values of the "sonarr" or "radarr" instance.
+// Concurrent refreshes of the same instance share a single fetch.
+func (c *CompletionIndex) refresh(ctx context.Context, name string) error {
+	_, err, _ := c.group.Do(name, func() (any, error) {
+		index, err := c.fetch(ctx, name)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		current := c.instances[name]
+		if err != nil {
+			current.failures++
+			current.retryAt = time.Now().Add(min(minRefreshBackoff<<(current.failures-1), maxRefreshBackoff))
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		index.loaded = true
+		index.refreshed = time.Now()
+		c.instances[name] = &index
+		return nil, nil
+	})
+	return err
+}
+
+// fetch reads the values of the "sonarr" or "radarr" instance.
+func (c *CompletionIndex) fetch(ctx context.Context, name string) (instanceIndex, error) {
+	var index instanceIndex
+	var source metadataSource
+	genreSet := make(map[string]struct{})
+	if name == "sonarr" {
+		series, err := c.sonarrClient.ListSeries(ctx)
+		if err != nil {
+			return index, fmt.Errorf("failed to list series: %w", err)
+		}
+		for _, s := range series {
+			index.entries = append(index.entries, libraryEntry{ID: s.ID, Title: s.Title, Certification: s.Certification, Genres: s.Genres, Overview: s.Overview})
+			for _, g := range s.Genres {
+				genreSet[g] = struct{}{}
+			}
+		}
+		source = c.sonarrClient
+	} else {
+		movies, err := c.radarrClient.ListMovies(ctx)
+		if err != nil {
+			return index, fmt.Errorf("failed to list movies: %w", err)
+		}
+		for _, mv := range movies {
+			index.entries = append(index.entries, libraryEntry{ID: mv.ID, Title: mv.Title, Certification: mv.Certification, Genres: mv.Genres, Overview: mv.Overview})
+			for _, g := range mv.Genres {
+				genreSet[g] = struct{}{}
+			}
+		}
+		source = c.radarrClient
+	}
+	for g := range genreSet {
+		index.genres = append(index.genres, g)
+	}
+
+	metadata, err := fetchMetadata(ctx, source)
+	if err != nil {
+		return index, err
+	}
+	index.metadata = metadata
+
+	return index, nil
+}
+
+// Invalidate marks the index as stale so the next completion refreshes it.
+func (c *CompletionIndex) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, index := range c.instances {
+		index.refreshed = time.Time{}
+	}
+}
+
+// TagID returns the ID of the tag with the given label in the "sonarr" or
+// "radarr" instance.
+func (c *CompletionIndex) TagID(ctx context.Context, source, label string) (int, bool) {
+	c.ensureFresh(ctx)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return lookupFold(c.instances[source].metadata.tags, label)
+}
+
+// QualityProfileID returns the ID of the quality profile with the given name
+// in the "sonarr" or "radarr" instance.
+func (c *CompletionIndex) QualityProfileID(ctx context.Context, source, name string) (int, bool) {
+	c.ensureFresh(ctx)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return lookupFold(c.instances[source].metadata.profiles, name)
+}
+
+// CompletePromptArgument implements server.PromptCompletionProvider.
+func (c *CompletionIndex) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, context mcp.CompleteContext) (*mcp.Completion, error) {
+	return c.complete(ctx, argument, context), nil
+}
+
+// CompleteResourceArgument implements server.ResourceCompletionProvider.
+func (c *CompletionIndex) CompleteResourceArgument(ctx context.Context, uri string, argument mcp.CompleteArgument, context mcp.CompleteContext) (*mcp.Completion, error) {
+	if argument.Name != "id" {
+		return c.complete(ctx, argument, context), nil
+	}
+
+	c.ensureFresh(ctx)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := c.instances["radarr"].entries
+	if strings.HasPrefix(uri, SeriesResourceURI) {
+		entries = c.instances["sonarr"].entries
+	}
+	entries, _ = c.allowed(ctx, entries, nil)
+
+	return completeIDs(entries, argument.Value), nil
+}
+
+func (c *CompletionIndex) complete(ctx context.Context, argument mcp.CompleteArgument, context mcp.CompleteContext) *mcp.Completion {
+	if argument.Name == "type" {
+		return completeValues([]string{"movie", "series"}, argument.Value)
+	}
+
+	c.ensureFresh(ctx)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch argument.Name {
+	case "genre":
+		var genres []string
+		for _, instance := range c.instances {
+			genres = append(genres, instance.genres...)
+		}
+		_, genres = c.allowed(ctx, nil, genres)
+		return completeValues(genres, argument.Value)
+	case "tag":
+		var tags []string
+		for _, instance := range c.instances {
+			tags = append(tags, mapKeys(instance.metadata.tags)...)
+		}
+		return completeValues(tags, argument.Value)
+	case "quality_profile":
+		var profiles []string
+		for _, instance := range c.instances {
+			profiles = append(profiles, mapKeys(instance.metadata.profiles)...)
+		}
+		return completeValues(profiles, argument.Value)
+	case "root_folder":
+		var folders []string
+		for _, instance := range c.instances {
+			folders = append(folders, instance.metadata.rootFolders...)
+		}
+		return completeValues(folders, argument.Value)
+	case "title", "similar_to":
+		var entries []libraryEntry
+		switch context.Arguments["type"] {
+		case "series":
+			entries = c.instances["sonarr"].entries
+		case "movie":
+			entries = c.instances["radarr"].entries
+		default:
+			entries = slices.Concat(c.instances["sonarr"].entries, c.instances["radarr"].entries)
+		}
+		entries, _ = c.allowed(ctx, entries, nil)
+		titles := make([]string, len(entries))
+		for i, e := range entries {
+			titles[i] = e.Title
+		}
+		return completeValues(titles, argument.Value)
+	default:
+		return &mcp.Completion{Values: []string{}}
+	}
+}
+
+// ensureFresh starts a refresh of each instance whose values are stale and
+// that is not backing off after a failure. The refresh runs in the
+// background, so the old values are served meanwhile, except for an instance
+// that has never been loaded, which is waited for until ctx is done.
+func (c *CompletionIndex) ensureFresh(ctx context.Context) {
+	now := time.Now()
+	for _, name := range []string{"sonarr", "radarr"} {
+		c.mu.RLock()
+		index := c.instances[name]
+		stale := now.Sub(index.refreshed) > c.interval && !now.Before(index.retryAt)
+		loaded := index.loaded
+		c.mu.RUnlock()
+
+		if !stale {
+			continue
+		}
+
+		done := c.group.DoChan(name+"/background", func() (any, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+			defer cancel()
+			if err := c.refresh(ctx, name); err != nil {
+				c.logger.Printf("Error refreshing completion index: %v", err)
+			}
+			return nil, nil
+		})
+		if !loaded {
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+func fetchMetadata(ctx context.Context, source metadataSource) (instanceMetadata, error) {
+	instance := instanceMetadata{
+		tags:     make(map[string]int),
+		profiles: make(map[string]int),
+	}
+
+	tags, err := source.Tags(ctx)
+	if err != nil {
+		return instance, fmt.Errorf("failed to get tags: %w", err)
+	}
+	for _, t := range tags {
+		instance.tags[t.Label] = t.ID
+	}
+
+	profiles, err := source.QualityProfiles(ctx)
+	if err != nil {
+		return instance, fmt.Errorf("failed to get quality profiles: %w", err)
+	}
+	for _, p := range profiles {
+		instance.profiles[p.Name] = p.ID
+	}
+
+	folders, err := source.RootFolders(ctx)
+	if err != nil {
+		return instance, fmt.Errorf("failed to get root folders: %w", err)
+	}
+	for _, f := range folders {
+		instance.rootFolders = append(instance.rootFolders, f.Path)
+	}
+
+	return instance, nil
+}
+
+// completeValues returns the candidates matching value, prefix matches first
+// then substring matches, ignoring case.
+func completeValues(candidates []string, value string) *mcp.Completion {
+	needle := strings.ToLower(value)
+
+	seen := make(map[string]struct{})
+	var prefix, contains []string
+	for _, candidate := range candidates {
+		if _, ok := seen[candidate]; ok {
+			continue
+		}
+		seen[candidate] = struct{}{}
+
+		lower := strings.ToLower(candidate)
+		switch {
+		case strings.HasPrefix(lower, needle):
+			prefix = append(prefix, candidate)
+		case strings.Contains(lower, needle):
+			contains = append(contains, candidate)
+		}
+	}
+	sort.Strings(prefix)
+	sort.Strings(contains)
+
+	return limitCompletion(append(prefix, contains...))
+}
+
+// completeIDs returns the IDs of entries whose ID starts with value or whose
+// title contains it.
+func completeIDs(entries []libraryEntry, value string) *mcp.Completion {
+	needle := strings.ToLower(value)
+
+	var ids []string
+	for _, e := range entries {
+		id := strconv.Itoa(e.ID)
+		if strings.HasPrefix(id, value) || strings.Contains(strings.ToLower(e.Title), needle) {
+			ids = append(ids, id)
+		}
+	}
+
+	return limitCompletion(ids)
+}
+
+func limitCompletion(values []string) *mcp.Completion {
+	completion := &mcp.Completion{
+		Values: values,
+		Total:  len(values),
+	}
+	if completion.Values == nil {
+		completion.Values = []string{}
+	}
+	if len(values) > maxCompletionValues {
+		completion.Values = values[:maxCompletionValues]
+		completion.HasMore = true
+	}
+	return completion
+}
+
+func mapKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func lookupFold(m map[string]int, key string) (int, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return 0, false
+}
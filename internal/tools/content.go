@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/events"
+	"github.com/IdoKendo/mcparr/internal/policy"
+)
+
+// WithContentRules restricts the movies and series each MCP client sees in
+// lookup results and may add, as returned by contentFor.
+func WithContentRules(contentFor func(client string) policy.Content) Option {
+	return func(m *MediaTools) {
+		m.contentFor = contentFor
+	}
+}
+
+// contentRules returns the content rules of the MCP client making the
+// request.
+func (m *MediaTools) contentRules(ctx context.Context) policy.Content {
+	if m.contentFor == nil {
+		return policy.Content{}
+	}
+	return m.contentFor(audit.User(ctx))
+}
+
+// allowedSeries returns the series the content rules allow.
+func allowedSeries(rules policy.Content, series []Series) []Series {
+	if rules.Empty() {
+		return series
+	}
+	var allowed []Series
+	for _, s := range series {
+		if rules.Check(s.Certification, s.Genres, s.Title, s.Overview) == "" {
+			allowed = append(allowed, s)
+		}
+	}
+	return allowed
+}
+
+// allowedMovies returns the movies the content rules allow.
+func allowedMovies(rules policy.Content, movies []Movie) []Movie {
+	if rules.Empty() {
+		return movies
+	}
+	var allowed []Movie
+	for _, movie := range movies {
+		if rules.Check(movie.Certification, movie.Genres, movie.Title, movie.Overview) == "" {
+			allowed = append(allowed, movie)
+		}
+	}
+	return allowed
+}
+
+// allowedCollectionMovies returns the movies of a collection the content
+// rules of the caller allow, and how many were left out. Collections don't
+// carry ratings, so each movie is looked up by ID when there are rules.
+func (m *MediaTools) allowedCollectionMovies(ctx context.Context, movies []CollectionMovie) ([]CollectionMovie, int) {
+	rules := m.contentRules(ctx)
+	if rules.Empty() {
+		return movies, 0
+	}
+
+	var allowed []CollectionMovie
+	for _, movie := range movies {
+		found, err := m.radarrClient.LookupMovie(ctx, fmt.Sprintf("tmdb:%d", movie.ID))
+		if err != nil {
+			m.logger.Printf("Error looking up movie %d for content rules: %v", movie.ID, err)
+			continue
+		}
+		if len(allowedMovies(rules, found)) > 0 {
+			allowed = append(allowed, movie)
+		}
+	}
+	return allowed, len(movies) - len(allowed)
+}
+
+// checkContent returns an error result if the content rules of the caller
+// don't allow the media to be added. The media is looked up by ID to read
+// its rating, genres and overview.
+func (m *MediaTools) checkContent(ctx context.Context, mediaType string, mediaID int) *mcp.CallToolResult {
+	rules := m.contentRules(ctx)
+	if rules.Empty() {
+		return nil
+	}
+
+	var reason string
+	switch mediaType {
+	case "series":
+		series, err := m.sonarrClient.LookupSeries(ctx, fmt.Sprintf("tvdb:%d", mediaID))
+		if err != nil {
+			m.logger.Printf("Error looking up series for content rules: %v", err)
+			return toolError("Failed to fetch data from Sonarr", err)
+		}
+		if len(series) == 0 {
+			reason = "its rating could not be checked"
+		} else {
+			reason = rules.Check(series[0].Certification, series[0].Genres, series[0].Title, series[0].Overview)
+		}
+	case "movie":
+		movies, err := m.radarrClient.LookupMovie(ctx, fmt.Sprintf("tmdb:%d", mediaID))
+		if err != nil {
+			m.logger.Printf("Error looking up movie for content rules: %v", err)
+			return toolError("Failed to fetch data from Radarr", err)
+		}
+		if len(movies) == 0 {
+			reason = "its rating could not be checked"
+		} else {
+			reason = rules.Check(movies[0].Certification, movies[0].Genres, movies[0].Title, movies[0].Overview)
+		}
+	}
+
+	if reason == "" {
+		return nil
+	}
+
+	client := audit.User(ctx)
+	audit.Deny(ctx, reason)
+	m.logger.Printf("Content rules of %s refused %s with ID %d: %s", client, mediaType, mediaID, reason)
+	return mcp.NewToolResultError(fmt.Sprintf(
+		"Content error: this %s is not allowed for %s because %s. Do not retry; tell the user it is not available.",
+		mediaType, client, reason))
+}
+
+// allowedTitles holds the library titles the content rules of a caller
+// allow, by TVDb or TMDb ID and by Sonarr or Radarr library ID.
+type allowedTitles struct {
+	series, movies              map[int]bool
+	seriesLibrary, movieLibrary map[int]bool
+}
+
+// allowedTitles returns the library titles the content rules of the caller
+// allow, or nil if there are no rules.
+func (m *MediaTools) allowedTitles(ctx context.Context) (*allowedTitles, error) {
+	rules := m.contentRules(ctx)
+	if rules.Empty() {
+		return nil, nil
+	}
+
+	series, err := m.sonarrClient.ListSeries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch series from Sonarr: %w", err)
+	}
+	movies, err := m.radarrClient.ListMovies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch movies from Radarr: %w", err)
+	}
+
+	allowed := &allowedTitles{
+		series:        make(map[int]bool),
+		movies:        make(map[int]bool),
+		seriesLibrary: make(map[int]bool),
+		movieLibrary:  make(map[int]bool),
+	}
+	for _, s := range allowedSeries(rules, series) {
+		allowed.series[s.ID] = true
+		allowed.seriesLibrary[s.LibraryID] = true
+	}
+	for _, movie := range allowedMovies(rules, movies) {
+		allowed.movies[movie.ID] = true
+		allowed.movieLibrary[movie.LibraryID] = true
+	}
+	return allowed, nil
+}
+
+// queueItem reports whether the title q is downloading is allowed.
+func (a *allowedTitles) queueItem(q QueueItem) bool {
+	if a == nil {
+		return true
+	}
+	if q.Source == "sonarr" {
+		return a.seriesLibrary[q.LibraryID]
+	}
+	return a.movieLibrary[q.LibraryID]
+}
+
+// event reports whether the title e is about is allowed. Titles can only be
+// checked while they are in the library, so events about deleted titles and
+// requests waiting for approval are left out; events about no title, such
+// as health issues, are kept.
+func (a *allowedTitles) event(e events.Event) bool {
+	switch {
+	case a == nil:
+		return true
+	case e.Source == "sonarr" && e.MediaID > 0:
+		return a.series[e.MediaID]
+	case e.Source == "radarr" && e.MediaID > 0:
+		return a.movies[e.MediaID]
+	default:
+		return e.MediaID == 0 && e.Title == ""
+	}
+}
+
+// allowedEvents returns the events the content rules of the caller allow.
+func (m *MediaTools) allowedEvents(ctx context.Context, recent []events.Event) ([]events.Event, error) {
+	allowed, err := m.allowedTitles(ctx)
+	if err != nil || allowed == nil {
+		return recent, err
+	}
+	var kept []events.Event
+	for _, e := range recent {
+		if allowed.event(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
+
+// EventAllowed reports whether the content rules of the MCP client in ctx
+// let it see e, so events pushed to clients are filtered like those they
+// list. An event that cannot be checked is not allowed.
+func (m *MediaTools) EventAllowed(ctx context.Context, e events.Event) bool {
+	allowed, err := m.allowedEvents(ctx, []events.Event{e})
+	if err != nil {
+		m.logger.Printf("Error checking event %d against content rules: %v", e.ID, err)
+		return false
+	}
+	return len(allowed) > 0
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/IdoKendo/mcparr/pkg/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// toolError returns a tool result explaining why action failed, translating
+// Sonarr and Radarr errors into something the model can act on.
+func toolError(action string, err error) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("%s: %s", action, describeError(err)))
+}
+
+// describeError explains err and, where possible, what to do about it.
+func describeError(err error) string {
+	if errors.Is(err, client.ErrCircuitOpen) {
+		return fmt.Sprintf("%v. Tell the user the service is down and try again later.", err)
+	}
+
+	var unauthorized *client.UnauthorizedError
+	if errors.As(err, &unauthorized) {
+		return fmt.Sprintf("%s rejected the API key. Ask the user to check the %s_API_KEY setting of mcparr.",
+			unauthorized.Instance, strings.ToUpper(unauthorized.Instance))
+	}
+
+	var notFound *client.NotFoundError
+	if errors.As(err, &notFound) {
+		return fmt.Sprintf("%s could not find it. The ID may be wrong or the item was removed; look it up again with search_media_id.",
+			notFound.Instance)
+	}
+
+	var validation *client.ValidationError
+	if errors.As(err, &validation) {
+		var alreadyAdded bool
+		messages := make([]string, len(validation.Fields))
+		for i, f := range validation.Fields {
+			messages[i] = f.Message
+			if strings.HasSuffix(f.Code, "ExistsValidator") {
+				alreadyAdded = true
+			}
+		}
+		if len(messages) == 0 {
+			messages = append(messages, validation.Message)
+		}
+
+		text := fmt.Sprintf("%s rejected the request: %s.", validation.Instance, strings.Join(messages, "; "))
+		if alreadyAdded {
+			return text + " It is already in the library, so there is nothing to do."
+		}
+		return text + " Fix the request before trying again."
+	}
+
+	var conflict *client.ConflictError
+	if errors.As(err, &conflict) {
+		reason := conflict.Message
+		if reason == "" {
+			reason = conflict.Status
+		}
+		return fmt.Sprintf("%s reported a conflict: %s. The item probably already exists.",
+			conflict.Instance, reason)
+	}
+
+	var serverErr *client.ServerError
+	if errors.As(err, &serverErr) {
+		return fmt.Sprintf("%s failed with %s. This is a problem on the server; try again later.",
+			serverErr.Instance, serverErr.Status)
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/events"
+)
+
+// EventLog is a simplified interface for the webhook event log.
+type EventLog interface {
+	Recent(limit int) []events.Event
+	Add(event events.Event) events.Event
+}
+
+// WithEvents sets the event log backing the recent_events tool.
+func WithEvents(eventLog EventLog) Option {
+	return func(m *MediaTools) {
+		m.events = eventLog
+	}
+}
+
+// RecentEvents returns a tool for listing recent Sonarr and Radarr events.
+func (m *MediaTools) RecentEvents() server.ServerTool {
+	tool := mcp.NewTool(
+		"recent_events",
+		mcp.WithDescription("List recent Sonarr and Radarr events such as grabs, imports, upgrades, deletes and health issues, and updates to requests waiting for approval"),
+		mcp.WithString(
+			"type",
+			mcp.Description("Only return events of this type (optional)"),
+			mcp.Enum(events.TypeGrab, events.TypeDownload, events.TypeUpgrade, events.TypeHealthIssue, events.TypeDelete,
+				events.TypeRequestPending, events.TypeRequestApproved, events.TypeRequestDenied),
+		),
+		mcp.WithNumber(
+			"limit",
+			mcp.Description("Maximum number of events to return (default: 10)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		eventType := request.GetString("type", "")
+		limit := request.GetInt("limit", 10)
+
+		updates := m.requestUpdates(ctx)
+		if m.events == nil {
+			return mcp.NewToolResultText(updates + "Event log is not enabled."), nil
+		}
+
+		m.logger.Printf("Listing recent events of type: %q, limit: %d", eventType, limit)
+
+		recent, err := m.allowedEvents(ctx, m.events.Recent(0))
+		if err != nil {
+			m.logger.Printf("Error checking events against content rules: %v", err)
+			return toolError("Failed to check events against content rules", err), nil
+		}
+
+		var matching []events.Event
+		for _, e := range recent {
+			if eventType != "" && e.Type != eventType {
+				continue
+			}
+			matching = append(matching, e)
+			if len(matching) >= limit {
+				break
+			}
+		}
+
+		if len(matching) == 0 {
+			return mcp.NewToolResultText(updates + "No recent events. Make sure the Sonarr and Radarr webhooks point at mcparr."), nil
+		}
+
+		var resultBuilder strings.Builder
+		resultBuilder.WriteString(updates)
+		resultBuilder.WriteString(fmt.Sprintf("Found %d recent events:\n", len(matching)))
+		for i, e := range matching {
+			resultBuilder.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, e.Time.Format("2006-01-02 15:04"), e.Message))
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
@@ -0,0 +1,244 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Prompts returns all the MCP prompts.
+func (m *MediaTools) Prompts() []server.ServerPrompt {
+	return []server.ServerPrompt{
+		m.WeeklyDigest(),
+		m.WhatToWatch(),
+		m.CleanUpLibrary(),
+		m.AddFromList(),
+	}
+}
+
+// WeeklyDigest returns a prompt summarizing recent and upcoming activity.
+func (m *MediaTools) WeeklyDigest() server.ServerPrompt {
+	prompt := mcp.NewPrompt(
+		"weekly_digest",
+		mcp.WithPromptDescription("Summarize what was downloaded recently and what is coming up"),
+		mcp.WithArgument(
+			"timeframe",
+			mcp.ArgumentDescription("How far back to look, e.g. 'week' or 'month' (default: week)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		timeframe := promptArgument(request, "timeframe", "week")
+
+		text := fmt.Sprintf(`Give me a digest of my media library for the past %[1]s.
+
+1. Call the recent_events tool and summarize what was grabbed, imported and upgraded in the past %[1]s. Mention any health issues first.
+2. Read the %[2]s resource and list what is still downloading, with progress.
+3. Read the %[3]s resource and list the episodes and movies releasing in the next 7 days.
+
+Keep it short and group items by movie and series.`, timeframe, QueueResourceURI, CalendarResourceURI)
+
+		return promptResult("Weekly media digest", text), nil
+	}
+
+	return server.ServerPrompt{
+		Prompt:  prompt,
+		Handler: handler,
+	}
+}
+
+// WhatToWatch returns a prompt recommending media for a mood.
+func (m *MediaTools) WhatToWatch() server.ServerPrompt {
+	prompt := mcp.NewPrompt(
+		"what_to_watch",
+		mcp.WithPromptDescription("Recommend something to watch based on mood"),
+		mcp.WithArgument(
+			"mood",
+			mcp.ArgumentDescription("How you feel or what you are in the mood for, e.g. 'cozy', 'tense', 'funny'"),
+			mcp.RequiredArgument(),
+		),
+		mcp.WithArgument(
+			"type",
+			mcp.ArgumentDescription("Either 'movie' or 'series' (default: either)"),
+		),
+		mcp.WithArgument(
+			"genre",
+			mcp.ArgumentDescription("A genre to prefer (optional)"),
+		),
+		mcp.WithArgument(
+			"similar_to",
+			mcp.ArgumentDescription("A title you enjoyed (optional)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		mood := promptArgument(request, "mood", "")
+		if mood == "" {
+			return nil, fmt.Errorf("mood is required")
+		}
+		mediaType := promptArgument(request, "type", "movie or series")
+		genre := promptArgument(request, "genre", "")
+		similarTo := promptArgument(request, "similar_to", "")
+
+		var text strings.Builder
+		text.WriteString(fmt.Sprintf("I'm in a %s mood. Recommend a %s for me to watch.\n", mood, mediaType))
+		if similarTo != "" {
+			text.WriteString(fmt.Sprintf("I enjoyed %s, so something similar would be great.\n", similarTo))
+		}
+		text.WriteString("\n")
+		text.WriteString(fmt.Sprintf("1. Check what I already have by reading the %s and %s resources, and prefer titles I own.\n", MoviesResourceURI, SeriesResourceURI))
+		if genre != "" {
+			text.WriteString(fmt.Sprintf("2. Use the search_by_genre tool with genre '%s' to find more options.\n", genre))
+		} else {
+			text.WriteString("2. Pick a genre that fits the mood and use the search_by_genre tool to find more options.\n")
+		}
+		text.WriteString("3. Suggest up to three titles with a one-line reason each, and say which ones are already in the library.\n")
+		text.WriteString("4. If I pick one I don't have, use search_media_id and then request_download to add it.")
+
+		return promptResult("What to watch", text.String()), nil
+	}
+
+	return server.ServerPrompt{
+		Prompt:  prompt,
+		Handler: handler,
+	}
+}
+
+// CleanUpLibrary returns a prompt suggesting library items to remove.
+func (m *MediaTools) CleanUpLibrary() server.ServerPrompt {
+	prompt := mcp.NewPrompt(
+		"clean_up_library",
+		mcp.WithPromptDescription("Find movies and series that could be removed to free up space"),
+		mcp.WithArgument(
+			"criteria",
+			mcp.ArgumentDescription("What to look for, e.g. 'ended series', 'movies without files' (default: anything stale)"),
+		),
+		mcp.WithArgument(
+			"tag",
+			mcp.ArgumentDescription("Only consider items with this tag (optional)"),
+		),
+		mcp.WithArgument(
+			"quality_profile",
+			mcp.ArgumentDescription("Only consider items using this quality profile (optional)"),
+		),
+		mcp.WithArgument(
+			"root_folder",
+			mcp.ArgumentDescription("Only consider items stored under this root folder (optional)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		criteria := promptArgument(request, "criteria", "ended series, movies that never downloaded, and duplicates")
+		tag := promptArgument(request, "tag", "")
+		qualityProfile := promptArgument(request, "quality_profile", "")
+		rootFolder := promptArgument(request, "root_folder", "")
+
+		var text strings.Builder
+		text.WriteString("Help me clean up my media library.\n\n")
+		text.WriteString(fmt.Sprintf("1. Read the %s and %s resources.\n", MoviesResourceURI, SeriesResourceURI))
+		text.WriteString(fmt.Sprintf("2. Find candidates for removal matching: %s.\n", criteria))
+		if tag != "" {
+			text.WriteString(fmt.Sprintf("   Only consider items tagged '%s'%s.\n", tag, m.describeIDs(ctx, "tags", tag, m.index.TagID)))
+		}
+		if qualityProfile != "" {
+			text.WriteString(fmt.Sprintf("   Only consider items using the '%s' quality profile%s.\n", qualityProfile, m.describeIDs(ctx, "qualityProfileId", qualityProfile, m.index.QualityProfileID)))
+		}
+		if rootFolder != "" {
+			text.WriteString(fmt.Sprintf("   Only consider items whose path starts with '%s'.\n", rootFolder))
+		}
+		text.WriteString("3. Present them as a table with the title, year, status and why it is a candidate.\n\n")
+		text.WriteString("Do not delete anything; wait for me to confirm which ones to remove.")
+
+		return promptResult("Clean up library", text.String()), nil
+	}
+
+	return server.ServerPrompt{
+		Prompt:  prompt,
+		Handler: handler,
+	}
+}
+
+// AddFromList returns a prompt that adds every title in a list.
+func (m *MediaTools) AddFromList() server.ServerPrompt {
+	prompt := mcp.NewPrompt(
+		"add_from_list",
+		mcp.WithPromptDescription("Add every movie or series from a pasted list"),
+		mcp.WithArgument(
+			"list",
+			mcp.ArgumentDescription("The titles to add, one per line or comma separated"),
+			mcp.RequiredArgument(),
+		),
+		mcp.WithArgument(
+			"type",
+			mcp.ArgumentDescription("Either 'movie' or 'series' if all titles are the same type (optional)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		list := promptArgument(request, "list", "")
+		if list == "" {
+			return nil, fmt.Errorf("list is required")
+		}
+		mediaType := promptArgument(request, "type", "")
+
+		var text strings.Builder
+		text.WriteString("Add the following titles to my library:\n\n")
+		text.WriteString(list)
+		text.WriteString("\n\nFor each title:\n")
+		if mediaType != "" {
+			text.WriteString(fmt.Sprintf("1. Use the search_media_id tool with type '%s' to find its ID.\n", mediaType))
+		} else {
+			text.WriteString("1. Decide whether it is a movie or a series, then use the search_media_id tool to find its ID.\n")
+		}
+		text.WriteString("2. Use the request_download tool with that ID.\n")
+		text.WriteString("3. If a title is ambiguous or not found, skip it.\n\n")
+		text.WriteString("Finish with a summary of what was added and what was skipped.")
+
+		return promptResult("Add from list", text.String()), nil
+	}
+
+	return server.ServerPrompt{
+		Prompt:  prompt,
+		Handler: handler,
+	}
+}
+
+// describeIDs resolves a tag or quality profile name into the per-instance
+// IDs found in the library resources, so the model can match on them.
+func (m *MediaTools) describeIDs(ctx context.Context, field, name string, resolve func(ctx context.Context, source, name string) (int, bool)) string {
+	if m.index == nil {
+		return ""
+	}
+
+	var ids []string
+	if id, ok := resolve(ctx, "sonarr", name); ok {
+		ids = append(ids, fmt.Sprintf("%d for series", id))
+	}
+	if id, ok := resolve(ctx, "radarr", name); ok {
+		ids = append(ids, fmt.Sprintf("%d for movies", id))
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%s %s)", field, strings.Join(ids, ", "))
+}
+
+func promptArgument(request mcp.GetPromptRequest, name, defaultValue string) string {
+	if value := strings.TrimSpace(request.Params.Arguments[name]); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(
+		description,
+		[]mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		},
+	)
+}
@@ -0,0 +1,329 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+)
+
+// maxListedItems is how many changed titles set_quality_profile lists.
+const maxListedItems = 20
+
+// libraryItem is a series or movie in the library.
+type libraryItem struct {
+	LibraryID int
+	Title     string
+	Year      int
+	Genres    []string
+	ProfileID int
+	Tags      []int
+}
+
+func (i libraryItem) String() string {
+	return fmt.Sprintf("%s (%d)", i.Title, i.Year)
+}
+
+// ListQualityProfiles returns a tool for listing the quality profiles.
+func (m *MediaTools) ListQualityProfiles() server.ServerTool {
+	tool := mcp.NewTool(
+		"list_quality_profiles",
+		mcp.WithDescription("List the quality profiles in Sonarr and Radarr with the qualities each allows and its cutoff, the quality after which upgrades stop"),
+		mcp.WithString(
+			"type",
+			mcp.Description("Only list the profiles of this type of media (optional)"),
+			mcp.Enum("movie", "series"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mediaType := request.GetString("type", "")
+
+		var resultBuilder strings.Builder
+		if mediaType != "movie" {
+			profiles, err := m.sonarrClient.QualityProfiles(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting Sonarr quality profiles: %v", err)
+				return toolError("Failed to fetch quality profiles from Sonarr", err), nil
+			}
+			writeQualityProfiles(&resultBuilder, "Sonarr", profiles)
+		}
+		if mediaType != "series" {
+			profiles, err := m.radarrClient.QualityProfiles(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting Radarr quality profiles: %v", err)
+				return toolError("Failed to fetch quality profiles from Radarr", err), nil
+			}
+			writeQualityProfiles(&resultBuilder, "Radarr", profiles)
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+func writeQualityProfiles(b *strings.Builder, instance string, profiles []QualityProfile) {
+	if len(profiles) == 0 {
+		fmt.Fprintf(b, "%s has no quality profiles.\n", instance)
+		return
+	}
+	fmt.Fprintf(b, "%s quality profiles:\n", instance)
+	for _, p := range profiles {
+		fmt.Fprintf(b, "- %s (ID: %d)", p.Name, p.ID)
+		if len(p.Allowed) > 0 {
+			fmt.Fprintf(b, ": allows %s", strings.Join(p.Allowed, ", "))
+		}
+		if p.Cutoff != "" {
+			fmt.Fprintf(b, "; upgrades until %s", p.Cutoff)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// findQualityProfile returns the profile named by value: its ID, its name, or
+// a resolution such as 4K.
+func findQualityProfile(profiles []QualityProfile, value string) (QualityProfile, bool) {
+	if id, err := strconv.Atoi(value); err == nil {
+		for _, p := range profiles {
+			if p.ID == id {
+				return p, true
+			}
+		}
+	}
+	for _, p := range profiles {
+		if strings.EqualFold(p.Name, value) {
+			return p, true
+		}
+	}
+	if quality, ok := ParseQuality(value); ok {
+		return matchQualityProfile(profiles, quality)
+	}
+	return QualityProfile{}, false
+}
+
+// SetQualityProfile returns a tool for moving library items to another
+// quality profile.
+func (m *MediaTools) SetQualityProfile() server.ServerTool {
+	tool := mcp.NewTool(
+		"set_quality_profile",
+		mcp.WithDescription("Change the quality profile of movies or series already in the library, to upgrade or downgrade them, "+
+			"e.g. move Oppenheimer to 4K or all animated shows to 720p. Select one title, or many by genre, tag or current profile."),
+		mcp.WithString(
+			"type",
+			mcp.Required(),
+			mcp.Description("The type of media to change"),
+			mcp.Enum("movie", "series"),
+		),
+		mcp.WithString(
+			"profile",
+			mcp.Required(),
+			mcp.Description("The quality profile to move to: its name or ID from list_quality_profiles, or a resolution such as 4K, 1080p or 720p"),
+		),
+		mcp.WithString(
+			"title",
+			mcp.Description("Only change the item with this title (optional)"),
+		),
+		mcp.WithString(
+			"genre",
+			mcp.Description("Only change items of this genre, e.g. Animation (optional)"),
+		),
+		mcp.WithString(
+			"tag",
+			mcp.Description("Only change items with this tag (optional)"),
+		),
+		mcp.WithString(
+			"from_profile",
+			mcp.Description("Only change items now in this quality profile (optional)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mediaType, err := request.RequireString("type")
+		if err != nil {
+			m.logger.Printf("Error getting media type: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid media type: %v", err)), nil
+		}
+
+		profileName, err := request.RequireString("profile")
+		if err != nil {
+			m.logger.Printf("Error getting quality profile: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid quality profile: %v", err)), nil
+		}
+
+		title := request.GetString("title", "")
+		genre := request.GetString("genre", "")
+		tag := request.GetString("tag", "")
+		fromProfile := request.GetString("from_profile", "")
+		if title == "" && genre == "" && tag == "" && fromProfile == "" {
+			return mcp.NewToolResultError("Select the items to change with title, genre, tag or from_profile."), nil
+		}
+
+		if !m.isAdmin(ctx) {
+			return mcp.NewToolResultError("Only admin clients can change quality profiles. Do not retry; tell the user an admin has to do this."), nil
+		}
+
+		if mediaType != "movie" && mediaType != "series" {
+			m.logger.Printf("Unsupported media type: %s", mediaType)
+			return mcp.NewToolResultText(fmt.Sprintf("Unsupported media type: %s. Must be 'movie' or 'series'.", mediaType)), nil
+		}
+		instance := instanceFor(mediaType)
+		noun := "movies"
+		if mediaType == "series" {
+			noun = "series"
+		}
+
+		var source metadataSource = m.radarrClient
+		if mediaType == "series" {
+			source = m.sonarrClient
+		}
+		profiles, err := source.QualityProfiles(ctx)
+		if err != nil {
+			m.logger.Printf("Error getting %s quality profiles: %v", instance, err)
+			return toolError("Failed to fetch quality profiles from "+instance, err), nil
+		}
+		profile, ok := findQualityProfile(profiles, profileName)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("%s has no quality profile '%s'. Use list_quality_profiles to see the profiles.", instance, profileName)), nil
+		}
+		fromID := 0
+		if fromProfile != "" {
+			from, ok := findQualityProfile(profiles, fromProfile)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("%s has no quality profile '%s'. Use list_quality_profiles to see the profiles.", instance, fromProfile)), nil
+			}
+			fromID = from.ID
+		}
+
+		items, err := m.libraryItems(ctx, mediaType)
+		if err != nil {
+			m.logger.Printf("Error listing %s library: %v", instance, err)
+			return toolError("Failed to fetch the library from "+instance, err), nil
+		}
+
+		tagID := 0
+		if tag != "" {
+			tags, err := source.Tags(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting %s tags: %v", instance, err)
+				return toolError("Failed to fetch tags from "+instance, err), nil
+			}
+			var found bool
+			if tagID, found = findTag(tags, tag); !found {
+				return mcp.NewToolResultText(fmt.Sprintf("%s has no tag '%s'. Use list_tags to see the tags.", instance, tag)), nil
+			}
+		}
+
+		q := ParseQuery(title)
+		var selected []libraryItem
+		var unchanged int
+		for _, item := range items {
+			switch {
+			case title != "" && !sameTitle(item, title, q):
+				continue
+			case genre != "" && !slices.ContainsFunc(item.Genres, func(g string) bool { return strings.EqualFold(g, genre) }):
+				continue
+			case tag != "" && !slices.Contains(item.Tags, tagID):
+				continue
+			case fromID != 0 && item.ProfileID != fromID:
+				continue
+			case item.ProfileID == profile.ID:
+				unchanged++
+				continue
+			}
+			selected = append(selected, item)
+		}
+
+		if len(selected) == 0 {
+			if unchanged > 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("All %d matching %s are already in the %s quality profile.", unchanged, noun, profile.Name)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("No %s in the library match. Nothing was changed.", noun)), nil
+		}
+
+		ids := make([]int, len(selected))
+		for i, item := range selected {
+			ids[i] = item.LibraryID
+		}
+		if len(selected) == 1 {
+			audit.Describe(ctx, instance, selected[0].Title, 0)
+		} else {
+			audit.Describe(ctx, instance, fmt.Sprintf("%d %s", len(selected), noun), 0)
+		}
+
+		m.logger.Printf("Moving %d %s to quality profile %s", len(selected), noun, profile.Name)
+		if mediaType == "series" {
+			err = m.sonarrClient.EditSeries(ctx, ids, profile.ID)
+		} else {
+			err = m.radarrClient.EditMovies(ctx, ids, profile.ID)
+		}
+		if err != nil {
+			m.logger.Printf("Error changing quality profile: %v", err)
+			audit.Fail(ctx, err)
+			return toolError("Failed to change the quality profile in "+instance, err), nil
+		}
+
+		var resultBuilder strings.Builder
+		resultBuilder.WriteString(fmt.Sprintf("Moved %d %s to the %s quality profile:\n", len(selected), noun, profile.Name))
+		for i, item := range selected[:min(len(selected), maxListedItems)] {
+			resultBuilder.WriteString(fmt.Sprintf("%d. %s\n", i+1, item))
+		}
+		if len(selected) > maxListedItems {
+			resultBuilder.WriteString(fmt.Sprintf("...and %d more.\n", len(selected)-maxListedItems))
+		}
+		if unchanged > 0 {
+			resultBuilder.WriteString(fmt.Sprintf("%d were already in that profile.\n", unchanged))
+		}
+		resultBuilder.WriteString(fmt.Sprintf("%s replaces their files when it finds releases that fit the profile.\n", instance))
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+// libraryItems returns the series or movies in the library.
+func (m *MediaTools) libraryItems(ctx context.Context, mediaType string) ([]libraryItem, error) {
+	var items []libraryItem
+	if mediaType == "series" {
+		series, err := m.sonarrClient.ListSeries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			items = append(items, libraryItem{LibraryID: s.LibraryID, Title: s.Title, Year: s.Year, Genres: s.Genres, ProfileID: s.ProfileID, Tags: s.Tags})
+		}
+		return items, nil
+	}
+
+	movies, err := m.radarrClient.ListMovies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, movie := range movies {
+		items = append(items, libraryItem{LibraryID: movie.LibraryID, Title: movie.Title, Year: movie.Year, Genres: movie.Genres, ProfileID: movie.ProfileID, Tags: movie.Tags})
+	}
+	return items, nil
+}
+
+// sameTitle reports whether item is the title asked for, as written or as
+// parsed by ParseQuery, in which case its year must match too.
+func sameTitle(item libraryItem, asked string, q Query) bool {
+	title := normalizeTitle(item.Title)
+	if title == normalizeTitle(asked) || title == normalizeTitle(q.Text) {
+		return true
+	}
+	return title == normalizeTitle(q.Title) && (q.Year == 0 || item.Year == q.Year)
+}
@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IdoKendo/mcparr/pkg/client"
+)
+
+// Query is a free-text request for a title, such as "Dune 2021 in 4K" or
+// "the office us s3", split into the title and the hints around it.
+type Query struct {
+	// Title is the text to look up.
+	Title string
+	// Text is the query without its season, episode and quality hints,
+	// looked up when the hints in it turn out to be part of the title, as in
+	// "Wonder Woman 1984".
+	Text    string
+	Year    int
+	Country string
+	Season  int
+	Episode int
+	// Quality is the resolution asked for: 2160p, 1080p, 720p or 480p.
+	Quality string
+
+	// countryWord is the country as written, put back by withoutCountry.
+	countryWord string
+}
+
+var (
+	seasonEpisodeRe = regexp.MustCompile(`^s(\d{1,2})(?:e(\d{1,3}))?$`)
+	crossEpisodeRe  = regexp.MustCompile(`^(\d{1,2})x(\d{1,3})$`)
+	yearRe          = regexp.MustCompile(`^(19|20)\d\d$`)
+)
+
+// qualityHints maps the words for a resolution to its name.
+var qualityHints = map[string]string{
+	"4k":     "2160p",
+	"uhd":    "2160p",
+	"2160p":  "2160p",
+	"1080p":  "1080p",
+	"fhd":    "1080p",
+	"fullhd": "1080p",
+	"720p":   "720p",
+	"480p":   "480p",
+	"sd":     "480p",
+}
+
+// countrySuffixes are the countries Sonarr adds to the titles of remakes,
+// as in "The Office (US)".
+var countrySuffixes = []string{"us", "uk", "au", "nz", "ca"}
+
+// ParseQuality returns the resolution named by hint, such as 2160p for "4K".
+func ParseQuality(hint string) (string, bool) {
+	quality, ok := qualityHints[strings.ToLower(strings.Join(strings.Fields(hint), ""))]
+	return quality, ok
+}
+
+// ParseQuery splits text into the title and its year, country, season,
+// episode and quality hints. External IDs are returned as the title as is.
+func ParseQuery(text string) Query {
+	text = strings.TrimSpace(text)
+	if _, exact := client.ParseExternalID(text); exact {
+		return Query{Title: text, Text: text}
+	}
+
+	var q Query
+	var kept []string
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		word := strings.ToLower(strings.Trim(fields[i], "()[],."))
+		next := ""
+		if i+1 < len(fields) {
+			next = strings.ToLower(strings.Trim(fields[i+1], "()[],."))
+		}
+
+		if quality, ok := ParseQuality(word); ok || (word == "full" && next == "hd") {
+			if !ok {
+				quality = "1080p"
+				i++
+			}
+			q.Quality = quality
+			// Drop the "in" of "in 4K".
+			if n := len(kept); n > 1 && strings.EqualFold(kept[n-1], "in") {
+				kept = kept[:n-1]
+			}
+			continue
+		}
+		if match := seasonEpisodeRe.FindStringSubmatch(word); match != nil {
+			q.Season, _ = strconv.Atoi(match[1])
+			q.Episode, _ = strconv.Atoi(match[2])
+			continue
+		}
+		if match := crossEpisodeRe.FindStringSubmatch(word); match != nil {
+			q.Season, _ = strconv.Atoi(match[1])
+			q.Episode, _ = strconv.Atoi(match[2])
+			continue
+		}
+		if number, err := strconv.Atoi(next); err == nil && len(kept) > 0 {
+			switch word {
+			case "season":
+				q.Season = number
+				i++
+				continue
+			case "episode", "ep":
+				q.Episode = number
+				i++
+				continue
+			}
+		}
+		kept = append(kept, fields[i])
+	}
+	q.Text = strings.Join(kept, " ")
+
+	// The year and country follow the title, which keeps at least one word,
+	// so "1917" and "Us" stay titles.
+	for len(kept) > 1 {
+		word := strings.ToLower(strings.Trim(kept[len(kept)-1], "()[],."))
+		if year, err := strconv.Atoi(word); err == nil && q.Year == 0 && yearRe.MatchString(word) && year <= time.Now().Year()+5 {
+			q.Year = year
+		} else if q.Country == "" && slices.Contains(countrySuffixes, word) {
+			q.Country = strings.ToUpper(word)
+			q.countryWord = kept[len(kept)-1]
+		} else {
+			break
+		}
+		kept = kept[:len(kept)-1]
+	}
+	q.Title = strings.Join(kept, " ")
+
+	return q
+}
+
+// Filtered reports whether the query narrows down the lookup results.
+func (q Query) Filtered() bool {
+	return q.Year != 0 || q.Country != "" || q.Season != 0
+}
+
+// matches reports whether a lookup result fits the year, country and season
+// of the query. A season count of zero is unknown and always fits.
+func (q Query) matches(title string, year, seasonCount int) bool {
+	switch {
+	case q.Year != 0 && year != q.Year:
+		return false
+	case q.Country != "" && !strings.HasSuffix(strings.ToUpper(title), "("+q.Country+")"):
+		return false
+	case q.Season != 0 && seasonCount != 0 && seasonCount < q.Season:
+		return false
+	default:
+		return true
+	}
+}
+
+// fromCountry reports whether title is the title of the query from its
+// country, as "The Office (US)" is for "the office us".
+func (q Query) fromCountry(title string) bool {
+	suffix := "(" + q.Country + ")"
+	if len(title) < len(suffix) || !strings.EqualFold(title[len(title)-len(suffix):], suffix) {
+		return false
+	}
+	return normalizeTitle(title[:len(title)-len(suffix)]) == normalizeTitle(q.Title)
+}
+
+// withoutCountry returns the query with its country read as the end of the
+// title, as in "This Is Us".
+func (q Query) withoutCountry() Query {
+	q.Title = strings.TrimSpace(q.Title + " " + q.countryWord)
+	q.Country, q.countryWord = "", ""
+	return q
+}
+
+// String describes the hints of the query, e.g. "2021, season 3, 2160p".
+func (q Query) String() string {
+	var hints []string
+	if q.Year != 0 {
+		hints = append(hints, strconv.Itoa(q.Year))
+	}
+	if q.Country != "" {
+		hints = append(hints, q.Country)
+	}
+	if q.Season != 0 {
+		hints = append(hints, fmt.Sprintf("season %d", q.Season))
+	}
+	if q.Episode != 0 {
+		hints = append(hints, fmt.Sprintf("episode %d", q.Episode))
+	}
+	if q.Quality != "" {
+		hints = append(hints, q.Quality)
+	}
+	return strings.Join(hints, ", ")
+}
+
+// describe tells how the query was read, e.g. " (read as 'the office' with
+// US, season 3)", or returns an empty string if it has no hints.
+func (q Query) describe() string {
+	hints := q.String()
+	if hints == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (read as '%s' with %s)", q.Title, hints)
+}
+
+// profileResolutions returns the resolutions the name of a quality profile
+// mentions, e.g. 720p and 1080p for "HD - 720p/1080p".
+func profileResolutions(name string) []string {
+	name = normalizeTitle(name)
+	var resolutions []string
+	if strings.Contains(name, "2160") || strings.Contains(name, "4k") || strings.Contains(name, "uhd") || strings.Contains(name, "ultrahd") {
+		resolutions = append(resolutions, "2160p")
+	}
+	if strings.Contains(name, "1080") {
+		resolutions = append(resolutions, "1080p")
+	}
+	if strings.Contains(name, "720") {
+		resolutions = append(resolutions, "720p")
+	}
+	if strings.Contains(name, "480") || name == "sd" || strings.HasPrefix(name, "sd") {
+		resolutions = append(resolutions, "480p")
+	}
+	return resolutions
+}
+
+// matchQualityProfile returns the profile meant for quality: the one whose
+// name, or else whose allowed qualities, mention it and the fewest other
+// resolutions.
+func matchQualityProfile(profiles []QualityProfile, quality string) (QualityProfile, bool) {
+	var best QualityProfile
+	bestCount := 0
+	for _, p := range profiles {
+		resolutions := profileResolutions(p.Name)
+		if len(resolutions) == 0 {
+			resolutions = profileResolutions(strings.Join(p.Allowed, " "))
+		}
+		if slices.Contains(resolutions, quality) && (bestCount == 0 || len(resolutions) < bestCount) {
+			best, bestCount = p, len(resolutions)
+		}
+	}
+	return best, bestCount > 0
+}
+
+// qualityProfileFor returns the ID of the Sonarr or Radarr quality profile
+// for quality, or the default profile if quality is empty.
+func (m *MediaTools) qualityProfileFor(ctx context.Context, mediaType, quality string) (int, error) {
+	if quality == "" {
+		return m.config.DefaultQualityProfileID(), nil
+	}
+
+	var source metadataSource = m.radarrClient
+	if mediaType == "series" {
+		source = m.sonarrClient
+	}
+
+	profiles, err := source.QualityProfiles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	profile, ok := matchQualityProfile(profiles, quality)
+	if !ok {
+		names := make([]string, len(profiles))
+		for i, p := range profiles {
+			names[i] = p.Name
+		}
+		return 0, fmt.Errorf("no %s quality profile for %s; the profiles are %s", instanceFor(mediaType), quality, strings.Join(names, ", "))
+	}
+
+	m.logger.Printf("Using quality profile %s for %s", profile.Name, quality)
+	return profile.ID, nil
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/approval"
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/policy"
+)
+
+// WithQuotas limits how many movies and series each MCP client may add per
+// period, as returned by quotaFor. Usage is counted from the audit trail, so
+// it survives restarts; without WithAuditTrail quotas are not enforced.
+func WithQuotas(quotaFor func(client string) policy.Quota, period time.Duration) Option {
+	return func(m *MediaTools) {
+		m.quotaFor = quotaFor
+		m.quotaPeriod = period
+	}
+}
+
+// quotaReservation is quota taken by a request that is being added and is
+// not in the audit trail yet, which records it only once the call returns.
+type quotaReservation struct {
+	client   string
+	instance string
+	mediaID  int
+	time     time.Time
+}
+
+// recorded reports whether the audit trail has an entry for the reserved
+// request.
+func (r quotaReservation) recorded(entries []audit.Entry) bool {
+	return slices.ContainsFunc(entries, func(e audit.Entry) bool {
+		return e.Instance == r.instance && e.MediaID == r.mediaID && !e.Time.Before(r.time)
+	})
+}
+
+// quotaUsage counts the movies and series client added, or asked to add,
+// in the current quota period, including reservations of requests being
+// added. Requests an admin denied don't count. m.quotaMu must be held.
+func (m *MediaTools) quotaUsage(client string) (policy.Quota, error) {
+	since := time.Now().Add(-m.quotaPeriod)
+	entries, err := m.auditTrail.Query(audit.Filter{
+		Since:  since,
+		User:   client,
+		Action: audit.ActionAdd,
+	})
+	if err != nil {
+		return policy.Quota{}, err
+	}
+
+	var used policy.Quota
+	count := func(instance string, n int) {
+		switch instance {
+		case "Radarr":
+			used.Movies += n
+		case "Sonarr":
+			used.Series += n
+		}
+	}
+
+	for _, e := range entries {
+		// An approval adds a request already counted against the requester
+		// when it was queued, not against the admin approving it.
+		if e.Tool == "approve_request" {
+			continue
+		}
+		if e.Outcome == audit.OutcomeSuccess || e.Outcome == audit.OutcomePending {
+			count(e.Instance, 1)
+		}
+	}
+
+	m.reservations = slices.DeleteFunc(m.reservations, func(r quotaReservation) bool {
+		return r.time.Before(since)
+	})
+	for _, r := range m.reservations {
+		if r.client == client && !r.recorded(entries) {
+			count(r.instance, 1)
+		}
+	}
+
+	if m.approvals != nil {
+		requests, err := m.approvals.Requests(client)
+		if err != nil {
+			return policy.Quota{}, err
+		}
+		for _, r := range requests {
+			if r.Status == approval.StatusDenied && !r.Time.Before(since) {
+				count(instanceFor(r.Type), -1)
+			}
+		}
+	}
+
+	used.Movies, used.Series = max(used.Movies, 0), max(used.Series, 0)
+	return used, nil
+}
+
+// checkQuota reserves quota for the caller to add media of mediaType, or
+// returns an error result if it has none left. It returns a note on how much
+// is left after adding it, and a function releasing the reservation, to be
+// called if the media is not added. The note is empty when quotas are not
+// enforced.
+func (m *MediaTools) checkQuota(ctx context.Context, mediaType string, mediaID int) (string, func(), *mcp.CallToolResult) {
+	if m.quotaFor == nil || m.auditTrail == nil {
+		return "", func() {}, nil
+	}
+
+	client := audit.User(ctx)
+	quota := m.quotaFor(client)
+	limit, noun := quota.Movies, "movies"
+	if mediaType == "series" {
+		limit, noun = quota.Series, "series"
+	}
+	if limit <= 0 {
+		return "", func() {}, nil
+	}
+
+	// Check and reserve under one lock, so concurrent requests can't all
+	// pass the check before any of them is recorded.
+	m.quotaMu.Lock()
+	defer m.quotaMu.Unlock()
+
+	used, err := m.quotaUsage(client)
+	if err != nil {
+		m.logger.Printf("Error counting quota usage for %s: %v", client, err)
+		return "", nil, mcp.NewToolResultError(fmt.Sprintf("Failed to check the quota: %v", err))
+	}
+	count := used.Movies
+	if mediaType == "series" {
+		count = used.Series
+	}
+
+	if count >= limit {
+		reason := fmt.Sprintf("the quota of %d %s per %s is used up", limit, noun, formatPeriod(m.quotaPeriod))
+		audit.Deny(ctx, reason)
+		return "", nil, mcp.NewToolResultError(fmt.Sprintf(
+			"Quota error: %s cannot add more %s because %s. Do not retry; tell the user to wait or ask an admin.",
+			client, noun, reason))
+	}
+
+	reservation := &quotaReservation{client: client, instance: instanceFor(mediaType), mediaID: mediaID, time: time.Now()}
+	m.reservations = append(m.reservations, *reservation)
+	release := func() {
+		m.quotaMu.Lock()
+		defer m.quotaMu.Unlock()
+		m.reservations = slices.DeleteFunc(m.reservations, func(r quotaReservation) bool {
+			return r == *reservation
+		})
+	}
+
+	return fmt.Sprintf("Quota: %d of %d %s left over the last %s.", limit-count-1, limit, noun, formatPeriod(m.quotaPeriod)), release, nil
+}
+
+// MyQuota returns a tool for checking the caller's request quota.
+func (m *MediaTools) MyQuota() server.ServerTool {
+	tool := mcp.NewTool(
+		"my_quota",
+		mcp.WithDescription("Show how many more movies and series the current user may request"),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		updates := m.requestUpdates(ctx)
+		if m.quotaFor == nil || m.auditTrail == nil {
+			return mcp.NewToolResultText(updates + "Quotas are not enabled; there is no limit on requests."), nil
+		}
+
+		client := audit.User(ctx)
+		quota := m.quotaFor(client)
+		m.quotaMu.Lock()
+		used, err := m.quotaUsage(client)
+		m.quotaMu.Unlock()
+		if err != nil {
+			m.logger.Printf("Error counting quota usage for %s: %v", client, err)
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to check the quota: %v", err)), nil
+		}
+
+		period := formatPeriod(m.quotaPeriod)
+		result := updates + fmt.Sprintf("Quota for %s over the last %s:\n- Movies: %s\n- Series: %s",
+			client, period, formatQuota(used.Movies, quota.Movies), formatQuota(used.Series, quota.Series))
+
+		return mcp.NewToolResultText(result), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+func formatQuota(used, limit int) string {
+	if limit <= 0 {
+		return fmt.Sprintf("%d requested, unlimited", used)
+	}
+	return fmt.Sprintf("%d of %d requested, %d left", used, limit, max(limit-used, 0))
+}
+
+// formatPeriod describes a quota period, e.g. "7 days".
+func formatPeriod(period time.Duration) string {
+	switch {
+	case period == 24*time.Hour:
+		return "day"
+	case period%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", period/(24*time.Hour))
+	default:
+		return period.String()
+	}
+}
@@ -0,0 +1,400 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/events"
+)
+
+// Resource URIs published by mcparr.
+const (
+	MoviesResourceURI   = "mcparr://movies"
+	SeriesResourceURI   = "mcparr://series"
+	CalendarResourceURI = "mcparr://calendar/week"
+	QueueResourceURI    = "mcparr://queue"
+	EventsResourceURI   = "mcparr://events"
+
+	movieTemplateURI  = "mcparr://movies/{id}"
+	seriesTemplateURI = "mcparr://series/{id}"
+)
+
+// calendarEntry is a single item in the combined calendar resource.
+type calendarEntry struct {
+	Type  string    `json:"type"`
+	Title string    `json:"title"`
+	Date  time.Time `json:"date"`
+	ID    int       `json:"id"`
+}
+
+// WithResourceNotifier sets the function called with the URIs of resources
+// changed by a tool call.
+func WithResourceNotifier(notify func(uris ...string)) Option {
+	return func(m *MediaTools) {
+		m.notify = notify
+	}
+}
+
+// Resources returns all the MCP resources.
+func (m *MediaTools) Resources() []server.ServerResource {
+	return []server.ServerResource{
+		m.MoviesResource(),
+		m.SeriesResource(),
+		m.CalendarResource(),
+		m.QueueResource(),
+		m.EventsResource(),
+	}
+}
+
+// ResourceTemplates returns all the MCP resource templates.
+func (m *MediaTools) ResourceTemplates() []server.ServerResourceTemplate {
+	return []server.ServerResourceTemplate{
+		m.MovieResourceTemplate(),
+		m.SeriesResourceTemplate(),
+	}
+}
+
+// MovieResourceURIFor returns the URI of the resource for a single movie.
+func MovieResourceURIFor(tmdbID int) string {
+	return fmt.Sprintf("%s/%d", MoviesResourceURI, tmdbID)
+}
+
+// SeriesResourceURIFor returns the URI of the resource for a single series.
+func SeriesResourceURIFor(tvdbID int) string {
+	return fmt.Sprintf("%s/%d", SeriesResourceURI, tvdbID)
+}
+
+// ResourcesForEvent returns the URIs of the resources affected by an event.
+func ResourcesForEvent(e events.Event) []string {
+	uris := []string{EventsResourceURI}
+
+	switch e.Type {
+	case events.TypeGrab:
+		uris = append(uris, QueueResourceURI)
+	case events.TypeDownload, events.TypeUpgrade, events.TypeDelete:
+		uris = append(uris, QueueResourceURI, CalendarResourceURI)
+		switch e.Source {
+		case "sonarr":
+			uris = append(uris, SeriesResourceURI)
+			if e.MediaID > 0 {
+				uris = append(uris, SeriesResourceURIFor(e.MediaID))
+			}
+		case "radarr":
+			uris = append(uris, MoviesResourceURI)
+			if e.MediaID > 0 {
+				uris = append(uris, MovieResourceURIFor(e.MediaID))
+			}
+		}
+	}
+
+	return uris
+}
+
+// MoviesResource returns the resource listing the Radarr library.
+func (m *MediaTools) MoviesResource() server.ServerResource {
+	resource := mcp.NewResource(
+		MoviesResourceURI,
+		"Movies",
+		mcp.WithResourceDescription("Every movie in the Radarr library"),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		movies, err := m.radarrClient.ListMovies(ctx)
+		if err != nil {
+			m.logger.Printf("Error listing movies: %v", err)
+			return nil, fmt.Errorf("failed to fetch movies from Radarr: %w", err)
+		}
+		return jsonContents(MoviesResourceURI, allowedMovies(m.contentRules(ctx), movies))
+	}
+
+	return server.ServerResource{
+		Resource: resource,
+		Handler:  handler,
+	}
+}
+
+// SeriesResource returns the resource listing the Sonarr library.
+func (m *MediaTools) SeriesResource() server.ServerResource {
+	resource := mcp.NewResource(
+		SeriesResourceURI,
+		"Series",
+		mcp.WithResourceDescription("Every series in the Sonarr library"),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		series, err := m.sonarrClient.ListSeries(ctx)
+		if err != nil {
+			m.logger.Printf("Error listing series: %v", err)
+			return nil, fmt.Errorf("failed to fetch series from Sonarr: %w", err)
+		}
+		return jsonContents(SeriesResourceURI, allowedSeries(m.contentRules(ctx), series))
+	}
+
+	return server.ServerResource{
+		Resource: resource,
+		Handler:  handler,
+	}
+}
+
+// CalendarResource returns the resource listing this week's episodes and
+// movie releases.
+func (m *MediaTools) CalendarResource() server.ServerResource {
+	resource := mcp.NewResource(
+		CalendarResourceURI,
+		"Calendar for the next 7 days",
+		mcp.WithResourceDescription("Episodes airing and movies releasing in the next 7 days"),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		start := time.Now()
+		end := start.AddDate(0, 0, 7)
+
+		episodes, err := m.sonarrClient.Calendar(ctx, start, end)
+		if err != nil {
+			m.logger.Printf("Error getting Sonarr calendar: %v", err)
+			return nil, fmt.Errorf("failed to fetch calendar from Sonarr: %w", err)
+		}
+
+		movies, err := m.radarrClient.Calendar(ctx, start, end)
+		if err != nil {
+			m.logger.Printf("Error getting Radarr calendar: %v", err)
+			return nil, fmt.Errorf("failed to fetch calendar from Radarr: %w", err)
+		}
+
+		allowedIDs, err := m.allowedSeriesIDs(ctx)
+		if err != nil {
+			m.logger.Printf("Error listing series for content rules: %v", err)
+			return nil, fmt.Errorf("failed to fetch series from Sonarr: %w", err)
+		}
+
+		var entries []calendarEntry
+		for _, e := range episodes {
+			if e.AirDateUTC == nil {
+				continue
+			}
+			if allowedIDs != nil && !allowedIDs[e.SeriesID] {
+				continue
+			}
+			entries = append(entries, calendarEntry{
+				Type:  "episode",
+				Title: fmt.Sprintf("%s S%02dE%02d - %s", e.SeriesTitle, e.SeasonNumber, e.EpisodeNumber, e.Title),
+				Date:  *e.AirDateUTC,
+				ID:    e.SeriesID,
+			})
+		}
+		for _, mv := range allowedMovies(m.contentRules(ctx), movies) {
+			for _, release := range []*time.Time{mv.InCinemas, mv.DigitalRelease, mv.PhysicalRelease} {
+				if release != nil && !release.Before(start) && release.Before(end) {
+					entries = append(entries, calendarEntry{
+						Type:  "movie",
+						Title: mv.Title,
+						Date:  *release,
+						ID:    mv.ID,
+					})
+				}
+			}
+		}
+
+		return jsonContents(CalendarResourceURI, entries)
+	}
+
+	return server.ServerResource{
+		Resource: resource,
+		Handler:  handler,
+	}
+}
+
+// QueueResource returns the resource listing the combined download queue.
+func (m *MediaTools) QueueResource() server.ServerResource {
+	resource := mcp.NewResource(
+		QueueResourceURI,
+		"Download queue",
+		mcp.WithResourceDescription("Items currently downloading in Sonarr and Radarr"),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		seriesQueue, err := m.sonarrClient.Queue(ctx)
+		if err != nil {
+			m.logger.Printf("Error getting Sonarr queue: %v", err)
+			return nil, fmt.Errorf("failed to fetch queue from Sonarr: %w", err)
+		}
+
+		movieQueue, err := m.radarrClient.Queue(ctx)
+		if err != nil {
+			m.logger.Printf("Error getting Radarr queue: %v", err)
+			return nil, fmt.Errorf("failed to fetch queue from Radarr: %w", err)
+		}
+
+		allowed, err := m.allowedTitles(ctx)
+		if err != nil {
+			m.logger.Printf("Error checking queue against content rules: %v", err)
+			return nil, err
+		}
+
+		var queue []QueueItem
+		for _, q := range append(seriesQueue, movieQueue...) {
+			if allowed.queueItem(q) {
+				queue = append(queue, q)
+			}
+		}
+		return jsonContents(QueueResourceURI, queue)
+	}
+
+	return server.ServerResource{
+		Resource: resource,
+		Handler:  handler,
+	}
+}
+
+// EventsResource returns the resource listing recent events.
+func (m *MediaTools) EventsResource() server.ServerResource {
+	resource := mcp.NewResource(
+		EventsResourceURI,
+		"Recent events",
+		mcp.WithResourceDescription("Recent Sonarr and Radarr events received through webhooks"),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		var recent []events.Event
+		if m.events != nil {
+			var err error
+			recent, err = m.allowedEvents(ctx, m.events.Recent(0))
+			if err != nil {
+				m.logger.Printf("Error checking events against content rules: %v", err)
+				return nil, err
+			}
+		}
+		return jsonContents(EventsResourceURI, recent)
+	}
+
+	return server.ServerResource{
+		Resource: resource,
+		Handler:  handler,
+	}
+}
+
+// MovieResourceTemplate returns the resource template for a single library
+// movie, addressed by TMDb ID.
+func (m *MediaTools) MovieResourceTemplate() server.ServerResourceTemplate {
+	template := mcp.NewResourceTemplate(
+		movieTemplateURI,
+		"Movie",
+		mcp.WithTemplateDescription("A movie in the Radarr library by TMDb ID"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		id, err := resourceID(request.Params.URI, MoviesResourceURI)
+		if err != nil {
+			return nil, err
+		}
+
+		movie, err := m.radarrClient.GetMovie(ctx, id)
+		if err != nil {
+			m.logger.Printf("Error getting movie %d: %v", id, err)
+			return nil, fmt.Errorf("failed to fetch movie from Radarr: %w", err)
+		}
+		if len(allowedMovies(m.contentRules(ctx), []Movie{movie})) == 0 {
+			return nil, fmt.Errorf("movie %d is not allowed by the content rules", id)
+		}
+		return jsonContents(request.Params.URI, movie)
+	}
+
+	return server.ServerResourceTemplate{
+		Template: template,
+		Handler:  handler,
+	}
+}
+
+// SeriesResourceTemplate returns the resource template for a single library
+// series, addressed by TVDb ID.
+func (m *MediaTools) SeriesResourceTemplate() server.ServerResourceTemplate {
+	template := mcp.NewResourceTemplate(
+		seriesTemplateURI,
+		"Series",
+		mcp.WithTemplateDescription("A series in the Sonarr library by TVDb ID"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		id, err := resourceID(request.Params.URI, SeriesResourceURI)
+		if err != nil {
+			return nil, err
+		}
+
+		series, err := m.sonarrClient.GetSeries(ctx, id)
+		if err != nil {
+			m.logger.Printf("Error getting series %d: %v", id, err)
+			return nil, fmt.Errorf("failed to fetch series from Sonarr: %w", err)
+		}
+		if len(allowedSeries(m.contentRules(ctx), []Series{series})) == 0 {
+			return nil, fmt.Errorf("series %d is not allowed by the content rules", id)
+		}
+		return jsonContents(request.Params.URI, series)
+	}
+
+	return server.ServerResourceTemplate{
+		Template: template,
+		Handler:  handler,
+	}
+}
+
+// allowedSeriesIDs returns the TVDb IDs of the library series the content
+// rules of the caller allow, or nil if there are no rules.
+func (m *MediaTools) allowedSeriesIDs(ctx context.Context) (map[int]bool, error) {
+	rules := m.contentRules(ctx)
+	if rules.Empty() {
+		return nil, nil
+	}
+
+	series, err := m.sonarrClient.ListSeries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[int]bool)
+	for _, s := range allowedSeries(rules, series) {
+		allowed[s.ID] = true
+	}
+	return allowed, nil
+}
+
+func (m *MediaTools) resourcesChanged(uris ...string) {
+	if m.notify != nil {
+		m.notify(uris...)
+	}
+}
+
+func resourceID(uri, prefix string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(uri, prefix+"/"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid resource URI %q: %w", uri, err)
+	}
+	return id, nil
+}
+
+func jsonContents(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", uri, err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
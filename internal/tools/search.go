@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultSearchLimit is how many results the search tool returns by default.
+const defaultSearchLimit = 10
+
+// searchResult is a lookup result of the search tool.
+type searchResult struct {
+	candidate
+	mediaType string
+	// position is the rank of the result in its instance's lookup.
+	position int
+	score    int
+}
+
+// titleScore rates how well title matches query: 3 for the same title, 2 if
+// it starts with the query, 1 if it contains it, and 0 otherwise.
+func titleScore(query, title string) int {
+	query, title = normalizeTitle(query), normalizeTitle(title)
+	switch {
+	case query == "":
+		return 0
+	case title == query:
+		return 3
+	case strings.HasPrefix(title, query):
+		return 2
+	case strings.Contains(title, query):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// rankResults orders results by how well their title matches the title of
+// query, or its text with the year and country left in, then by their rank
+// in their instance's lookup, series first on ties. It drops repeated titles
+// of the same type and year.
+func rankResults(query Query, results []searchResult) []searchResult {
+	for i := range results {
+		results[i].score = max(titleScore(query.Title, results[i].Title), titleScore(query.Text, results[i].Title))
+	}
+	slices.SortStableFunc(results, func(a, b searchResult) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return a.position - b.position
+	})
+
+	type key struct {
+		mediaType string
+		title     string
+		year      int
+	}
+	seen := make(map[key]bool)
+	ranked := results[:0]
+	for _, r := range results {
+		k := key{r.mediaType, normalizeTitle(r.Title), r.Year}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		ranked = append(ranked, r)
+	}
+	return ranked
+}
+
+// Search returns a tool for searching movies and series at once.
+func (m *MediaTools) Search() server.ServerTool {
+	tool := mcp.NewTool(
+		"search",
+		mcp.WithDescription("Search movies and TV shows at once by name, when it's not known which the user means, "+
+			"e.g. Fargo is both a film and a show. Returns one ranked list with the type, instance and ID of each result."),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of media to find, or an IMDb, TMDb or TVDb ID or URL"),
+		),
+		mcp.WithNumber(
+			"limit",
+			mcp.Description(fmt.Sprintf("Maximum number of results to return (default: %d)", defaultSearchLimit)),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			m.logger.Printf("Error getting media name: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid media name: %v", err)), nil
+		}
+		limit := request.GetInt("limit", defaultSearchLimit)
+
+		m.logger.Printf("Searching movies and series with name: %s", name)
+
+		// Look both instances up concurrently. A failing instance is
+		// reported next to the results of the other, so the group never
+		// fails.
+		mediaTypes := []string{"series", "movie"}
+		found := make([][]searchResult, len(mediaTypes))
+		queries := make([]Query, len(mediaTypes))
+		errs := make([]error, len(mediaTypes))
+		var group errgroup.Group
+		for i, mediaType := range mediaTypes {
+			group.Go(func() error {
+				candidates, query, err := m.lookupCandidates(ctx, mediaType, name)
+				queries[i] = query
+				if err != nil {
+					m.logger.Printf("Error looking up %s %q: %v", mediaType, name, err)
+					errs[i] = err
+					return nil
+				}
+				for j, c := range candidates {
+					found[i] = append(found[i], searchResult{candidate: c, mediaType: mediaType, position: j})
+				}
+				return nil
+			})
+		}
+		group.Wait()
+
+		var failures []string
+		for i, err := range errs {
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", instanceFor(mediaTypes[i]), describeError(err)))
+			}
+		}
+		if len(failures) == len(mediaTypes) {
+			return mcp.NewToolResultError("Failed to search: " + strings.Join(failures, "; ")), nil
+		}
+
+		// Describe the query as read by the lookups, which may have found
+		// its country or year to be part of the title.
+		query := ParseQuery(name)
+		for i, q := range queries {
+			if errs[i] == nil && q.Title != query.Title {
+				query = q
+			}
+		}
+		results := rankResults(query, slices.Concat(found...))
+		if limit > 0 && len(results) > limit {
+			results = results[:limit]
+		}
+
+		var resultBuilder strings.Builder
+		if len(results) == 0 {
+			resultBuilder.WriteString(fmt.Sprintf("No movies or series found for '%s'%s.\n", name, query.describe()))
+		} else {
+			resultBuilder.WriteString(fmt.Sprintf("Found %d results for '%s'%s:\n", len(results), name, query.describe()))
+			for i, r := range results {
+				resultBuilder.WriteString(fmt.Sprintf("%d. %s - %s in %s", i+1, r.candidate, r.mediaType, instanceFor(r.mediaType)))
+				if r.InLibrary {
+					resultBuilder.WriteString(", already in the library")
+				}
+				resultBuilder.WriteString("\n")
+			}
+			resultBuilder.WriteString("Use the type and ID of the result the user means with request_download.\n")
+		}
+		for _, failure := range failures {
+			resultBuilder.WriteString(fmt.Sprintf("Could not search %s\n", failure))
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AutoRootFolder, as the shows or movies root path, adds each item to the
+// root folder with the most free space.
+const AutoRootFolder = "auto"
+
+// errLowSpace is returned when a root folder is below the minimum free space
+// and adding to it is refused.
+var errLowSpace = errors.New("not enough free space")
+
+// SpaceSettings sets how much free space root folders should keep.
+type SpaceSettings struct {
+	// MinFree is the free space in bytes below which adding to a root folder
+	// is warned about, or refused if Refuse is set. Zero disables the check.
+	MinFree int64
+	Refuse  bool
+}
+
+// WithSpaceCheck warns about or refuses adding to root folders that are low
+// on free space.
+func WithSpaceCheck(settings SpaceSettings) Option {
+	return func(m *MediaTools) {
+		m.space = settings
+	}
+}
+
+// configuredRootFolder returns the root path configured for mediaType and
+// the instance it belongs to.
+func (m *MediaTools) configuredRootFolder(mediaType string) (string, metadataSource) {
+	if mediaType == "series" {
+		return m.config.ShowsRootPath(), m.sonarrClient
+	}
+	return m.config.MoviesRootPath(), m.radarrClient
+}
+
+// rootFolderFor returns the root folder to add media of mediaType to: the
+// configured one, or the one with the most free space if it is
+// AutoRootFolder. If the folder is below the minimum free space, it returns
+// a warning, or errLowSpace if that is refused. The free space is read anew
+// for every add, as the clients don't cache root folders.
+func (m *MediaTools) rootFolderFor(ctx context.Context, mediaType string) (string, string, error) {
+	path, source := m.configuredRootFolder(mediaType)
+	if path != AutoRootFolder && m.space.MinFree <= 0 {
+		return path, "", nil
+	}
+
+	folders, err := source.RootFolders(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	var folder RootFolder
+	found := false
+	if path == AutoRootFolder {
+		folder, found = mostFreeFolder(folders)
+		if !found {
+			return "", "", fmt.Errorf("%s has no accessible root folder", instanceFor(mediaType))
+		}
+		path = folder.Path
+		m.logger.Printf("Chose root folder %s with %s free", path, formatBytes(folder.FreeSpace))
+	} else if i := slices.IndexFunc(folders, func(f RootFolder) bool { return samePath(f.Path, path) }); i >= 0 {
+		folder, found = folders[i], true
+	}
+
+	if !found || m.space.MinFree <= 0 || folder.FreeSpace >= m.space.MinFree {
+		return path, "", nil
+	}
+
+	reason := fmt.Sprintf("%s has %s free, below the minimum of %s", path, formatBytes(folder.FreeSpace), formatBytes(m.space.MinFree))
+	if m.space.Refuse {
+		return "", "", fmt.Errorf("%w: %s", errLowSpace, reason)
+	}
+	m.logger.Printf("Low disk space: %s", reason)
+	return path, fmt.Sprintf("Warning: %s. Tell the user to free some space.", reason), nil
+}
+
+// mostFreeFolder returns the accessible root folder with the most free space.
+func mostFreeFolder(folders []RootFolder) (RootFolder, bool) {
+	var best RootFolder
+	found := false
+	for _, f := range folders {
+		if f.Accessible && (!found || f.FreeSpace > best.FreeSpace) {
+			best, found = f, true
+		}
+	}
+	return best, found
+}
+
+// samePath reports whether two folder paths are the same, ignoring a
+// trailing slash.
+func samePath(a, b string) bool {
+	return strings.TrimRight(a, `/\`) == strings.TrimRight(b, `/\`)
+}
+
+// diskFor returns the disk holding path: the one with the longest mount path
+// that contains it.
+func diskFor(disks []DiskSpace, path string) (DiskSpace, bool) {
+	var best DiskSpace
+	found := false
+	for _, d := range disks {
+		mount := strings.TrimRight(d.Path, `/\`)
+		if path != mount && !strings.HasPrefix(path, mount+"/") && !strings.HasPrefix(path, mount+`\`) {
+			continue
+		}
+		if !found || len(d.Path) > len(best.Path) {
+			best, found = d, true
+		}
+	}
+	return best, found
+}
+
+// formatBytes returns a size in bytes as GB or TB.
+func formatBytes(size int64) string {
+	const gb = 1 << 30
+	if size >= 1024*gb {
+		return fmt.Sprintf("%.1f TB", float64(size)/(1024*gb))
+	}
+	return fmt.Sprintf("%.1f GB", float64(size)/gb)
+}
+
+// DiskSpaceTool returns a tool for reporting the root folders and their free
+// space.
+func (m *MediaTools) DiskSpaceTool() server.ServerTool {
+	tool := mcp.NewTool(
+		"disk_space",
+		mcp.WithDescription("Show the Sonarr and Radarr root folders with their free and total space, and which one new items go to"),
+		mcp.WithString(
+			"type",
+			mcp.Description("Only show the root folders of this type of media (optional)"),
+			mcp.Enum("movie", "series"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mediaType := request.GetString("type", "")
+
+		var resultBuilder strings.Builder
+		for _, t := range []string{"series", "movie"} {
+			if mediaType != "" && mediaType != t {
+				continue
+			}
+
+			instance := instanceFor(t)
+			configured, source := m.configuredRootFolder(t)
+			folders, err := source.RootFolders(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting %s root folders: %v", instance, err)
+				return toolError("Failed to fetch root folders from "+instance, err), nil
+			}
+			disks, err := source.DiskSpace(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting %s disk space: %v", instance, err)
+				return toolError("Failed to fetch disk space from "+instance, err), nil
+			}
+			m.writeRootFolders(&resultBuilder, instance, configured, folders, disks)
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+func (m *MediaTools) writeRootFolders(b *strings.Builder, instance, configured string, folders []RootFolder, disks []DiskSpace) {
+	if len(folders) == 0 {
+		fmt.Fprintf(b, "%s has no root folders.\n", instance)
+		return
+	}
+
+	chosen := configured
+	if configured == AutoRootFolder {
+		if best, ok := mostFreeFolder(folders); ok {
+			chosen = best.Path
+		}
+	}
+
+	fmt.Fprintf(b, "%s root folders:\n", instance)
+	for _, f := range folders {
+		fmt.Fprintf(b, "- %s: ", f.Path)
+		if !f.Accessible {
+			b.WriteString("not accessible")
+		} else if disk, ok := diskFor(disks, f.Path); ok && disk.TotalSpace > 0 {
+			fmt.Fprintf(b, "%s free of %s (%d%% used)", formatBytes(f.FreeSpace), formatBytes(disk.TotalSpace),
+				100-f.FreeSpace*100/disk.TotalSpace)
+		} else {
+			fmt.Fprintf(b, "%s free", formatBytes(f.FreeSpace))
+		}
+		if m.space.MinFree > 0 && f.Accessible && f.FreeSpace < m.space.MinFree {
+			fmt.Fprintf(b, ", below the minimum of %s", formatBytes(m.space.MinFree))
+		}
+		if samePath(f.Path, chosen) {
+			if configured == AutoRootFolder {
+				b.WriteString(" (new items go here, it has the most free space)")
+			} else {
+				b.WriteString(" (new items go here)")
+			}
+		}
+		b.WriteString("\n")
+	}
+	if configured != AutoRootFolder && !slices.ContainsFunc(folders, func(f RootFolder) bool { return samePath(f.Path, configured) }) {
+		fmt.Fprintf(b, "The configured root folder %s is not one of them, so adding to %s will fail.\n", configured, instance)
+	}
+}
+
+// rootFolderError explains why no root folder of the instance could be used.
+func (m *MediaTools) rootFolderError(instance string, err error) *mcp.CallToolResult {
+	m.logger.Printf("Error choosing %s root folder: %v", instance, err)
+	if errors.Is(err, errLowSpace) {
+		return mcp.NewToolResultError(fmt.Sprintf(
+			"Disk space error: %v. Do not retry; tell the user to free some space or add a root folder in %s.", err, instance))
+	}
+	return toolError("Failed to choose a "+instance+" root folder", err)
+}
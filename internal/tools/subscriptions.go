@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Subscriptions tracks which MCP sessions subscribed to which resource URIs
+// and tells them when those resources change.
+type Subscriptions struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]struct{}
+	server   *server.MCPServer
+	logger   *log.Logger
+}
+
+// NewSubscriptions creates a new Subscriptions and registers the hooks that
+// keep it up to date.
+func NewSubscriptions(hooks *server.Hooks) *Subscriptions {
+	s := &Subscriptions{
+		sessions: make(map[string]map[string]struct{}),
+		logger:   log.Default(),
+	}
+
+	hooks.AddAfterSubscribe(func(ctx context.Context, id any, message *mcp.SubscribeRequest, result *mcp.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			s.subscribe(session.SessionID(), message.Params.URI)
+		}
+	})
+	hooks.AddAfterUnsubscribe(func(ctx context.Context, id any, message *mcp.UnsubscribeRequest, result *mcp.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			s.unsubscribe(session.SessionID(), message.Params.URI)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.removeSession(session.SessionID())
+	})
+
+	return s
+}
+
+// Attach sets the server used to deliver notifications.
+func (s *Subscriptions) Attach(srv *server.MCPServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.server = srv
+}
+
+// Notify sends notifications/resources/updated to every session subscribed
+// to one of the given URIs.
+func (s *Subscriptions) Notify(uris ...string) {
+	s.mu.Lock()
+	srv := s.server
+	targets := make(map[string][]string)
+	for _, uri := range uris {
+		for sessionID := range s.sessions[uri] {
+			targets[sessionID] = append(targets[sessionID], uri)
+		}
+	}
+	s.mu.Unlock()
+
+	if srv == nil {
+		return
+	}
+
+	for sessionID, sessionURIs := range targets {
+		for _, uri := range sessionURIs {
+			err := srv.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+				"uri": uri,
+			})
+			if err != nil {
+				s.logger.Printf("Error notifying session %s about %s: %v", sessionID, uri, err)
+			}
+		}
+	}
+}
+
+func (s *Subscriptions) subscribe(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions[uri] == nil {
+		s.sessions[uri] = make(map[string]struct{})
+	}
+	s.sessions[uri][sessionID] = struct{}{}
+}
+
+func (s *Subscriptions) unsubscribe(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions[uri], sessionID)
+	if len(s.sessions[uri]) == 0 {
+		delete(s.sessions, uri)
+	}
+}
+
+func (s *Subscriptions) removeSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uri, sessions := range s.sessions {
+		delete(sessions, sessionID)
+		if len(sessions) == 0 {
+			delete(s.sessions, uri)
+		}
+	}
+}
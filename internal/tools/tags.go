@@ -0,0 +1,294 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RequesterTagPrefix starts the label of the tag naming who requested an
+// item, e.g. "requested-by-claude-desktop".
+const RequesterTagPrefix = "requested-by-"
+
+// TagSettings selects the tags applied to every item mcparr adds.
+type TagSettings struct {
+	// Requester tags each item with the MCP client that requested it.
+	Requester bool
+	// Movies and Series are extra tags for movies and series.
+	Movies []string
+	Series []string
+}
+
+// WithTags sets the tags applied to every item mcparr adds, creating them in
+// Sonarr or Radarr if missing.
+func WithTags(settings TagSettings) Option {
+	return func(m *MediaTools) {
+		m.tags = settings
+	}
+}
+
+// TagLabel turns name into a valid Sonarr and Radarr tag label, which may
+// only hold lowercase letters, digits and dashes.
+func TagLabel(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// tagClient is the part of the Sonarr and Radarr clients that manages tags.
+type tagClient interface {
+	Tags(ctx context.Context) ([]Tag, error)
+	CreateTag(ctx context.Context, label string) (Tag, error)
+}
+
+// requestTags returns the IDs of the tags to apply to media of mediaType
+// requested by requester, creating the missing ones.
+func (m *MediaTools) requestTags(ctx context.Context, mediaType, requester string) ([]int, error) {
+	var labels []string
+	if m.tags.Requester && requester != "" {
+		labels = append(labels, RequesterTagPrefix+TagLabel(requester))
+	}
+
+	var instance tagClient = m.radarrClient
+	extra := m.tags.Movies
+	if mediaType == "series" {
+		instance = m.sonarrClient
+		extra = m.tags.Series
+	}
+	for _, label := range extra {
+		labels = append(labels, TagLabel(label))
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	// Serialize creating tags, so concurrent requests don't create the same
+	// tag twice.
+	m.tagMu.Lock()
+	defer m.tagMu.Unlock()
+
+	existing, err := instance.Tags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(labels))
+	for _, label := range labels {
+		id, found := findTag(existing, label)
+		if !found {
+			tag, err := instance.CreateTag(ctx, label)
+			if err != nil {
+				return nil, err
+			}
+			m.logger.Printf("Created tag %s with ID: %d", tag.Label, tag.ID)
+			existing = append(existing, tag)
+			id = tag.ID
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// findTag returns the ID of the tag with the given label.
+func findTag(tags []Tag, label string) (int, bool) {
+	for _, t := range tags {
+		if strings.EqualFold(t.Label, label) {
+			return t.ID, true
+		}
+	}
+	return 0, false
+}
+
+// ListTags returns a tool for listing the tags in Sonarr and Radarr.
+func (m *MediaTools) ListTags() server.ServerTool {
+	tool := mcp.NewTool(
+		"list_tags",
+		mcp.WithDescription("List the tags in Sonarr and Radarr and how many items have each, such as the requested-by tags naming who asked for what"),
+		mcp.WithString(
+			"type",
+			mcp.Description("Only list the tags of this type of media (optional)"),
+			mcp.Enum("movie", "series"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mediaType := request.GetString("type", "")
+
+		var resultBuilder strings.Builder
+		if mediaType != "movie" {
+			tags, err := m.sonarrClient.Tags(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting Sonarr tags: %v", err)
+				return toolError("Failed to fetch tags from Sonarr", err), nil
+			}
+			series, err := m.sonarrClient.ListSeries(ctx)
+			if err != nil {
+				m.logger.Printf("Error listing series: %v", err)
+				return toolError("Failed to fetch series from Sonarr", err), nil
+			}
+
+			counts := make(map[int]int)
+			for _, s := range series {
+				for _, id := range s.Tags {
+					counts[id]++
+				}
+			}
+			writeTags(&resultBuilder, "Sonarr", "series", tags, counts)
+		}
+		if mediaType != "series" {
+			tags, err := m.radarrClient.Tags(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting Radarr tags: %v", err)
+				return toolError("Failed to fetch tags from Radarr", err), nil
+			}
+			movies, err := m.radarrClient.ListMovies(ctx)
+			if err != nil {
+				m.logger.Printf("Error listing movies: %v", err)
+				return toolError("Failed to fetch movies from Radarr", err), nil
+			}
+
+			counts := make(map[int]int)
+			for _, movie := range movies {
+				for _, id := range movie.Tags {
+					counts[id]++
+				}
+			}
+			writeTags(&resultBuilder, "Radarr", "movies", tags, counts)
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
+
+func writeTags(b *strings.Builder, instance, noun string, tags []Tag, counts map[int]int) {
+	if len(tags) == 0 {
+		fmt.Fprintf(b, "%s has no tags.\n", instance)
+		return
+	}
+	fmt.Fprintf(b, "%s tags:\n", instance)
+	for _, t := range tags {
+		fmt.Fprintf(b, "- %s: %d %s\n", t.Label, counts[t.ID], noun)
+	}
+}
+
+// LibraryByTag returns a tool for listing the library items with a tag.
+func (m *MediaTools) LibraryByTag() server.ServerTool {
+	tool := mcp.NewTool(
+		"library_by_tag",
+		mcp.WithDescription("List the movies or series in the library that have a tag, e.g. everything requested by a user"),
+		mcp.WithString(
+			"type",
+			mcp.Required(),
+			mcp.Description("The type of media to list"),
+			mcp.Enum("movie", "series"),
+		),
+		mcp.WithString(
+			"tag",
+			mcp.Required(),
+			mcp.Description("The tag label, from list_tags"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mediaType, err := request.RequireString("type")
+		if err != nil {
+			m.logger.Printf("Error getting media type: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid media type: %v", err)), nil
+		}
+
+		label, err := request.RequireString("tag")
+		if err != nil {
+			m.logger.Printf("Error getting tag: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid tag: %v", err)), nil
+		}
+
+		m.logger.Printf("Listing %s with tag: %s", mediaType, label)
+
+		var titles []string
+		switch mediaType {
+		case "series":
+			tags, err := m.sonarrClient.Tags(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting Sonarr tags: %v", err)
+				return toolError("Failed to fetch tags from Sonarr", err), nil
+			}
+			id, found := findTag(tags, label)
+			if !found {
+				return mcp.NewToolResultText(fmt.Sprintf("Sonarr has no tag '%s'. Use list_tags to see the tags.", label)), nil
+			}
+
+			series, err := m.sonarrClient.ListSeries(ctx)
+			if err != nil {
+				m.logger.Printf("Error listing series: %v", err)
+				return toolError("Failed to fetch series from Sonarr", err), nil
+			}
+			for _, s := range allowedSeries(m.contentRules(ctx), series) {
+				if slices.Contains(s.Tags, id) {
+					titles = append(titles, fmt.Sprintf("%s (%d) (ID: %d)", s.Title, s.Year, s.ID))
+				}
+			}
+		case "movie":
+			tags, err := m.radarrClient.Tags(ctx)
+			if err != nil {
+				m.logger.Printf("Error getting Radarr tags: %v", err)
+				return toolError("Failed to fetch tags from Radarr", err), nil
+			}
+			id, found := findTag(tags, label)
+			if !found {
+				return mcp.NewToolResultText(fmt.Sprintf("Radarr has no tag '%s'. Use list_tags to see the tags.", label)), nil
+			}
+
+			movies, err := m.radarrClient.ListMovies(ctx)
+			if err != nil {
+				m.logger.Printf("Error listing movies: %v", err)
+				return toolError("Failed to fetch movies from Radarr", err), nil
+			}
+			for _, movie := range allowedMovies(m.contentRules(ctx), movies) {
+				if slices.Contains(movie.Tags, id) {
+					titles = append(titles, fmt.Sprintf("%s (%d) (ID: %d)", movie.Title, movie.Year, movie.ID))
+				}
+			}
+		default:
+			m.logger.Printf("Unsupported media type: %s", mediaType)
+			return mcp.NewToolResultText(fmt.Sprintf("Unsupported media type: %s. Must be 'movie' or 'series'.", mediaType)), nil
+		}
+
+		if len(titles) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No %s in the library has the tag '%s'.", mediaType, label)), nil
+		}
+
+		var resultBuilder strings.Builder
+		resultBuilder.WriteString(fmt.Sprintf("Found %d items tagged '%s':\n", len(titles), label))
+		for i, title := range titles {
+			resultBuilder.WriteString(fmt.Sprintf("%d. %s\n", i+1, title))
+		}
+
+		return mcp.NewToolResultText(resultBuilder.String()), nil
+	}
+
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
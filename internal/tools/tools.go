@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/policy"
+	"github.com/IdoKendo/mcparr/pkg/client"
 )
 
 // MediaTools holds all the MCP tools for media management.
@@ -15,9 +21,26 @@ type MediaTools struct {
 	config       Config
 	sonarrClient SonarrClient
 	radarrClient RadarrClient
+	events       EventLog
+	notify       func(uris ...string)
+	index        *CompletionIndex
+	auditTrail   AuditTrail
+	approvals    ApprovalQueue
+	admins       []string
+	quotaFor     func(client string) policy.Quota
+	quotaPeriod  time.Duration
+	quotaMu      sync.Mutex
+	reservations []quotaReservation
+	tags         TagSettings
+	space        SpaceSettings
+	contentFor   func(client string) policy.Content
+	tagMu        sync.Mutex
 	logger       *log.Logger
 }
 
+// Option configures optional MediaTools dependencies.
+type Option func(*MediaTools)
+
 // Config is a simplified interface for the configuration.
 type Config interface {
 	SonarrURL() string
@@ -35,6 +58,16 @@ type SonarrClient interface {
 	RequestSeriesDownload(ctx context.Context, series Series, qualityProfileID int, rootFolderPath string) error
 	SearchSeriesByGenre(ctx context.Context, genre string, similarTo string, limit int) ([]Series, error)
 	RequestSeriesDelete(ctx context.Context, series Series) error
+	ListSeries(ctx context.Context) ([]Series, error)
+	GetSeries(ctx context.Context, tvdbID int) (Series, error)
+	Calendar(ctx context.Context, start, end time.Time) ([]Episode, error)
+	Queue(ctx context.Context) ([]QueueItem, error)
+	Tags(ctx context.Context) ([]Tag, error)
+	CreateTag(ctx context.Context, label string) (Tag, error)
+	QualityProfiles(ctx context.Context) ([]QualityProfile, error)
+	RootFolders(ctx context.Context) ([]RootFolder, error)
+	DiskSpace(ctx context.Context) ([]DiskSpace, error)
+	EditSeries(ctx context.Context, libraryIDs []int, qualityProfileID int) error
 }
 
 // RadarrClient is a simplified interface for the Radarr client.
@@ -43,40 +76,177 @@ type RadarrClient interface {
 	RequestMovieDownload(ctx context.Context, movie Movie, qualityProfileID int, rootFolderPath string) error
 	SearchMoviesByGenre(ctx context.Context, genre string, similarTo string, limit int) ([]Movie, error)
 	RequestMovieDelete(ctx context.Context, movie Movie) error
+	ListMovies(ctx context.Context) ([]Movie, error)
+	GetMovie(ctx context.Context, tmdbID int) (Movie, error)
+	Calendar(ctx context.Context, start, end time.Time) ([]Movie, error)
+	Queue(ctx context.Context) ([]QueueItem, error)
+	Tags(ctx context.Context) ([]Tag, error)
+	CreateTag(ctx context.Context, label string) (Tag, error)
+	QualityProfiles(ctx context.Context) ([]QualityProfile, error)
+	RootFolders(ctx context.Context) ([]RootFolder, error)
+	DiskSpace(ctx context.Context) ([]DiskSpace, error)
+	EditMovies(ctx context.Context, libraryIDs []int, qualityProfileID int) error
+	Collections(ctx context.Context, tmdbID int) ([]Collection, error)
+	UpdateCollection(ctx context.Context, collection Collection) error
 }
 
 // Series represents a TV series.
 type Series struct {
-	ID       int      `json:"tvdbId"`
-	Title    string   `json:"title"`
-	Overview string   `json:"overview,omitempty"`
-	Genres   []string `json:"genres,omitempty"`
+	ID            int      `json:"tvdbId"`
+	LibraryID     int      `json:"id,omitempty"`
+	Title         string   `json:"title"`
+	Year          int      `json:"year,omitempty"`
+	Status        string   `json:"status,omitempty"`
+	Overview      string   `json:"overview,omitempty"`
+	Genres        []string `json:"genres,omitempty"`
+	Certification string   `json:"certification,omitempty"`
+	ProfileID     int      `json:"qualityProfileId,omitempty"`
+	Path          string   `json:"path,omitempty"`
+	Tags          []int    `json:"tags,omitempty"`
+	// SeasonCount is the number of the last season, or zero if unknown.
+	SeasonCount int `json:"seasonCount,omitempty"`
 }
 
 // Movie represents a movie.
 type Movie struct {
-	ID       int      `json:"tmdbId"`
-	Title    string   `json:"title"`
-	Overview string   `json:"overview,omitempty"`
-	Genres   []string `json:"genres,omitempty"`
+	ID              int              `json:"tmdbId"`
+	LibraryID       int              `json:"id,omitempty"`
+	Title           string           `json:"title"`
+	Year            int              `json:"year,omitempty"`
+	Status          string           `json:"status,omitempty"`
+	Overview        string           `json:"overview,omitempty"`
+	Genres          []string         `json:"genres,omitempty"`
+	Certification   string           `json:"certification,omitempty"`
+	HasFile         bool             `json:"hasFile,omitempty"`
+	InCinemas       *time.Time       `json:"inCinemas,omitempty"`
+	DigitalRelease  *time.Time       `json:"digitalRelease,omitempty"`
+	PhysicalRelease *time.Time       `json:"physicalRelease,omitempty"`
+	ProfileID       int              `json:"qualityProfileId,omitempty"`
+	Path            string           `json:"path,omitempty"`
+	Tags            []int            `json:"tags,omitempty"`
+	Collection      *MovieCollection `json:"collection,omitempty"`
+}
+
+// MovieCollection identifies the TMDb collection of a movie.
+type MovieCollection struct {
+	Title  string `json:"title"`
+	TmdbID int    `json:"tmdbId"`
+}
+
+// Collection represents a TMDb collection tracked by Radarr.
+type Collection struct {
+	ID                  int               `json:"id"`
+	Title               string            `json:"title"`
+	TmdbID              int               `json:"tmdbId"`
+	Monitored           bool              `json:"monitored"`
+	QualityProfileID    int               `json:"qualityProfileId"`
+	RootFolderPath      string            `json:"rootFolderPath"`
+	MinimumAvailability string            `json:"minimumAvailability,omitempty"`
+	SearchOnAdd         bool              `json:"searchOnAdd"`
+	Movies              []CollectionMovie `json:"movies"`
+}
+
+// CollectionMovie is a movie of a collection.
+type CollectionMovie struct {
+	ID        int    `json:"tmdbId"`
+	Title     string `json:"title"`
+	Year      int    `json:"year"`
+	Status    string `json:"status"`
+	InLibrary bool   `json:"isExisting"`
+}
+
+// Episode represents an upcoming or recently aired episode.
+type Episode struct {
+	SeriesTitle   string     `json:"seriesTitle"`
+	SeriesID      int        `json:"tvdbId"`
+	SeasonNumber  int        `json:"seasonNumber"`
+	EpisodeNumber int        `json:"episodeNumber"`
+	Title         string     `json:"title"`
+	AirDateUTC    *time.Time `json:"airDateUtc,omitempty"`
+	HasFile       bool       `json:"hasFile"`
+}
+
+// QueueItem represents an item in a download queue.
+type QueueItem struct {
+	Source string `json:"source"`
+	// LibraryID is the Sonarr or Radarr ID of the series or movie being
+	// downloaded.
+	LibraryID               int        `json:"libraryId,omitempty"`
+	Title                   string     `json:"title"`
+	Status                  string     `json:"status"`
+	TrackedDownloadState    string     `json:"trackedDownloadState,omitempty"`
+	Size                    float64    `json:"size"`
+	SizeLeft                float64    `json:"sizeLeft"`
+	TimeLeft                string     `json:"timeLeft,omitempty"`
+	EstimatedCompletionTime *time.Time `json:"estimatedCompletionTime,omitempty"`
+}
+
+// Tag represents a tag.
+type Tag struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+}
+
+// QualityProfile represents a quality profile.
+type QualityProfile struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Allowed are the qualities the profile downloads, lowest first.
+	Allowed []string `json:"allowed,omitempty"`
+	// Cutoff is the quality after which upgrades stop.
+	Cutoff string `json:"cutoff,omitempty"`
+}
+
+// RootFolder represents a root folder.
+type RootFolder struct {
+	ID         int    `json:"id"`
+	Path       string `json:"path"`
+	Accessible bool   `json:"accessible"`
+	FreeSpace  int64  `json:"freeSpace"`
+}
+
+// DiskSpace represents a disk and its free space.
+type DiskSpace struct {
+	Path       string `json:"path"`
+	Label      string `json:"label"`
+	FreeSpace  int64  `json:"freeSpace"`
+	TotalSpace int64  `json:"totalSpace"`
 }
 
 // New creates a new MediaTools instance.
-func New(cfg Config, sonarrClient SonarrClient, radarrClient RadarrClient) *MediaTools {
-	return &MediaTools{
+func New(cfg Config, sonarrClient SonarrClient, radarrClient RadarrClient, opts ...Option) *MediaTools {
+	m := &MediaTools{
 		config:       cfg,
 		sonarrClient: sonarrClient,
 		radarrClient: radarrClient,
 		logger:       log.Default(),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Tools returns all the MCP tools.
 func (m *MediaTools) Tools() []server.ServerTool {
 	return []server.ServerTool{
+		m.Search(),
 		m.SearchMediaID(),
 		m.SearchByGenre(),
 		m.RequestDownload(),
+		m.RequestDownloadBatch(),
+		m.RecentEvents(),
+		m.AuditLog(),
+		m.ListPendingRequests(),
+		m.ApproveRequest(),
+		m.DenyRequest(),
+		m.MyQuota(),
+		m.ListTags(),
+		m.LibraryByTag(),
+		m.CollectionTool(),
+		m.ListQualityProfiles(),
+		m.SetQualityProfile(),
+		m.DiskSpaceTool(),
 	}
 }
 
@@ -94,7 +264,7 @@ func (m *MediaTools) SearchMediaID() server.ServerTool {
 		mcp.WithString(
 			"name",
 			mcp.Required(),
-			mcp.Description("The name of media to find"),
+			mcp.Description("The name of media to find, or an IMDb, TMDb or TVDb ID or URL for an exact match"),
 		),
 	)
 
@@ -112,40 +282,30 @@ func (m *MediaTools) SearchMediaID() server.ServerTool {
 		}
 
 		m.logger.Printf("Searching for %s with name: %s", mediaType, mediaName)
+		if mediaType != "movie" && mediaType != "series" {
+			m.logger.Printf("Unsupported media type: %s", mediaType)
+			return mcp.NewToolResultText(fmt.Sprintf("Unsupported media type: %s. Must be 'movie' or 'series'.", mediaType)), nil
+		}
+		externalID, exact := client.ParseExternalID(mediaName)
+		instance := instanceFor(mediaType)
 
-		var result string
-		switch mediaType {
-		case "series":
-			series, err := m.sonarrClient.LookupSeries(ctx, mediaName)
-			if err != nil {
-				m.logger.Printf("Error looking up series: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to fetch data from Sonarr: %v", err)), nil
-			}
-
-			if len(series) > 0 {
-				m.logger.Printf("Found series: %s with ID: %d", series[0].Title, series[0].ID)
-				result = fmt.Sprintf("Found Sonarr series with ID: %d", series[0].ID)
-			} else {
-				m.logger.Printf("No series found for: %s", mediaName)
-				result = "No matching series found in Sonarr."
-			}
-		case "movie":
-			movies, err := m.radarrClient.LookupMovie(ctx, mediaName)
-			if err != nil {
-				m.logger.Printf("Error looking up movie: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to fetch data from Radarr: %v", err)), nil
-			}
+		candidates, _, err := m.lookupCandidates(ctx, mediaType, mediaName)
+		if err != nil {
+			m.logger.Printf("Error looking up %s: %v", mediaType, err)
+			return toolError("Failed to fetch data from "+instance, err), nil
+		}
 
-			if len(movies) > 0 {
-				m.logger.Printf("Found movie: %s with ID: %d", movies[0].Title, movies[0].ID)
-				result = fmt.Sprintf("Found Radarr movie with ID: %d", movies[0].ID)
-			} else {
-				m.logger.Printf("No movie found for: %s", mediaName)
-				result = "No matching movie found in Radarr."
-			}
-		default:
-			m.logger.Printf("Unsupported media type: %s", mediaType)
-			result = fmt.Sprintf("Unsupported media type: %s. Must be 'movie' or 'series'.", mediaType)
+		var result string
+		if len(candidates) > 0 && exact {
+			m.logger.Printf("Found exact %s match for %s: %s with ID: %d", mediaType, externalID, candidates[0].Title, candidates[0].ID)
+			result = fmt.Sprintf("Found exact %s match for %s: %s (%d) with ID: %d. Use this ID with request_download.",
+				instance, externalID, candidates[0].Title, candidates[0].Year, candidates[0].ID)
+		} else if len(candidates) > 0 {
+			m.logger.Printf("Found %s: %s with ID: %d", mediaType, candidates[0].Title, candidates[0].ID)
+			result = fmt.Sprintf("Found %s %s with ID: %d", instance, mediaType, candidates[0].ID)
+		} else {
+			m.logger.Printf("No %s found for: %s", mediaType, mediaName)
+			result = fmt.Sprintf("No matching %s found in %s.", mediaType, instance)
 		}
 
 		return mcp.NewToolResultText(result), nil
@@ -208,8 +368,9 @@ func (m *MediaTools) SearchByGenre() server.ServerTool {
 			series, err := m.sonarrClient.SearchSeriesByGenre(ctx, genre, similarTo, limit)
 			if err != nil {
 				m.logger.Printf("Error searching series by genre: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to search series by genre: %v", err)), nil
+				return toolError("Failed to search series by genre", err), nil
 			}
+			series = allowedSeries(m.contentRules(ctx), series)
 
 			if len(series) > 0 {
 				m.logger.Printf("Found %d series matching genre: %s", len(series), genre)
@@ -230,8 +391,9 @@ func (m *MediaTools) SearchByGenre() server.ServerTool {
 			movies, err := m.radarrClient.SearchMoviesByGenre(ctx, genre, similarTo, limit)
 			if err != nil {
 				m.logger.Printf("Error searching movies by genre: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to search movies by genre: %v", err)), nil
+				return toolError("Failed to search movies by genre", err), nil
 			}
+			movies = allowedMovies(m.contentRules(ctx), movies)
 
 			if len(movies) > 0 {
 				m.logger.Printf("Found %d movies matching genre: %s", len(movies), genre)
@@ -308,9 +470,11 @@ func (m *MediaTools) RequestDelete() server.ServerTool {
 				ID:    mediaId,
 				Title: title,
 			}
+			audit.Describe(ctx, "Radarr", title, mediaId)
 			if err := m.radarrClient.RequestMovieDelete(ctx, movie); err != nil {
 				m.logger.Printf("Error requesting movie delete: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to request movie delete: %v", err)), nil
+				audit.Fail(ctx, err)
+				return toolError("Failed to request movie delete", err), nil
 			}
 			m.logger.Printf("Requested movie delete for ID: %d", mediaId)
 			result = fmt.Sprintf("Requested movie delete for ID: %d", mediaId)
@@ -319,9 +483,11 @@ func (m *MediaTools) RequestDelete() server.ServerTool {
 				ID:    mediaId,
 				Title: title,
 			}
+			audit.Describe(ctx, "Sonarr", title, mediaId)
 			if err := m.sonarrClient.RequestSeriesDelete(ctx, series); err != nil {
 				m.logger.Printf("Error requesting series delete: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to request series delete: %v", err)), nil
+				audit.Fail(ctx, err)
+				return toolError("Failed to request series delete", err), nil
 			}
 			m.logger.Printf("Requested series delete for ID: %d", mediaId)
 			result = fmt.Sprintf("Requested series delete for ID: %d", mediaId)
@@ -360,6 +526,10 @@ func (m *MediaTools) RequestDownload() server.ServerTool {
 			mcp.Required(),
 			mcp.Description("The ID of media to download"),
 		),
+		mcp.WithString(
+			"quality",
+			mcp.Description("The resolution to download in, such as 4K, 1080p or 720p, if the user asked for one (optional)"),
+		),
 	)
 
 	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -381,72 +551,156 @@ func (m *MediaTools) RequestDownload() server.ServerTool {
 			return mcp.NewToolResultError(fmt.Sprintf("Invalid media ID: %v", err)), nil
 		}
 
-		m.logger.Printf("Requesting download for %s: %s (ID: %d)", mediaType, mediaName, mediaID)
-
-		var result string
-		switch mediaType {
-		case "series":
-			series := Series{
-				ID:    mediaID,
-				Title: mediaName,
+		var quality string
+		if hint := request.GetString("quality", ""); hint != "" {
+			var ok bool
+			if quality, ok = ParseQuality(hint); !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid quality: %s. Use 4K, 1080p, 720p or SD.", hint)), nil
 			}
+		}
 
-			qualityProfileID := m.config.DefaultQualityProfileID()
-			rootFolderPath := m.config.ShowsRootPath()
+		return m.requestMedia(ctx, mediaType, mediaName, mediaID, quality), nil
+	}
 
-			m.logger.Printf("Using quality profile ID: %d and root folder: %s",
-				qualityProfileID, rootFolderPath)
+	return server.ServerTool{
+		Tool:    tool,
+		Handler: handler,
+	}
+}
 
-			err := m.sonarrClient.RequestSeriesDownload(
-				ctx,
-				series,
-				qualityProfileID,
-				rootFolderPath,
-			)
+// requestMedia checks the content rules and quota of the caller, then adds
+// the media in the quality profile for quality, or queues it for approval if
+// the caller is not an admin.
+func (m *MediaTools) requestMedia(ctx context.Context, mediaType, mediaName string, mediaID int, quality string) *mcp.CallToolResult {
+	if denied := m.checkContent(ctx, mediaType, mediaID); denied != nil {
+		return denied
+	}
 
-			if err != nil {
-				m.logger.Printf("Error requesting series download: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to request download from Sonarr: %v", err)), nil
-			}
+	quotaNote, release, denied := m.checkQuota(ctx, mediaType, mediaID)
+	if denied != nil {
+		return denied
+	}
 
-			m.logger.Printf("Successfully requested download for series: %s", mediaName)
-			result = fmt.Sprintf("Download requested for Sonarr series with ID: %d", mediaID)
-		case "movie":
-			movie := Movie{
-				ID:    mediaID,
-				Title: mediaName,
-			}
+	var result *mcp.CallToolResult
+	if m.approvals != nil && !m.isAdmin(ctx) {
+		result = m.holdDownload(ctx, mediaType, mediaName, mediaID, quality)
+	} else {
+		result = m.download(ctx, mediaType, mediaName, mediaID, audit.User(ctx), quality)
+	}
+	if result.IsError {
+		release()
+	}
 
-			qualityProfileID := m.config.DefaultQualityProfileID()
-			rootFolderPath := m.config.MoviesRootPath()
+	if quotaNote != "" && !result.IsError {
+		text := result.Content[0].(mcp.TextContent).Text
+		result = mcp.NewToolResultText(text + "\n" + quotaNote)
+	}
+	return result
+}
 
-			m.logger.Printf("Using quality profile ID: %d and root folder: %s",
-				qualityProfileID, rootFolderPath)
+// download adds the media to Sonarr or Radarr with the quality profile for
+// quality, or the default one, and the default root folder, tagged for
+// requester, and returns the result to report.
+func (m *MediaTools) download(ctx context.Context, mediaType, mediaName string, mediaID int, requester, quality string) *mcp.CallToolResult {
+	m.logger.Printf("Requesting download for %s: %s (ID: %d)", mediaType, mediaName, mediaID)
 
-			err := m.radarrClient.RequestMovieDownload(
-				ctx,
-				movie,
-				qualityProfileID,
-				rootFolderPath,
-			)
+	var result, spaceWarning string
+	switch mediaType {
+	case "series":
+		tags, err := m.requestTags(ctx, mediaType, requester)
+		if err != nil {
+			m.logger.Printf("Error resolving series tags: %v", err)
+			return toolError("Failed to create tags in Sonarr", err)
+		}
 
-			if err != nil {
-				m.logger.Printf("Error requesting movie download: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to request download from Radarr: %v", err)), nil
-			}
+		series := Series{
+			ID:    mediaID,
+			Title: mediaName,
+			Tags:  tags,
+		}
 
-			m.logger.Printf("Successfully requested download for movie: %s", mediaName)
-			result = fmt.Sprintf("Download requested for Radarr movie with ID: %d", mediaID)
-		default:
-			m.logger.Printf("Unsupported media type: %s", mediaType)
-			result = fmt.Sprintf("Unsupported media type: %s. Must be 'movie' or 'series'.", mediaType)
+		qualityProfileID, err := m.qualityProfileFor(ctx, mediaType, quality)
+		if err != nil {
+			m.logger.Printf("Error choosing quality profile: %v", err)
+			return toolError("Failed to choose a Sonarr quality profile", err)
 		}
+		rootFolderPath, warning, err := m.rootFolderFor(ctx, mediaType)
+		if err != nil {
+			return m.rootFolderError("Sonarr", err)
+		}
+		spaceWarning = warning
 
-		return mcp.NewToolResultText(result), nil
+		m.logger.Printf("Using quality profile ID: %d and root folder: %s",
+			qualityProfileID, rootFolderPath)
+
+		audit.Describe(ctx, "Sonarr", mediaName, mediaID)
+		err = m.sonarrClient.RequestSeriesDownload(
+			ctx,
+			series,
+			qualityProfileID,
+			rootFolderPath,
+		)
+
+		if err != nil {
+			m.logger.Printf("Error requesting series download: %v", err)
+			audit.Fail(ctx, err)
+			return toolError("Failed to request download from Sonarr", err)
+		}
+
+		m.logger.Printf("Successfully requested download for series: %s", mediaName)
+		m.resourcesChanged(SeriesResourceURI, SeriesResourceURIFor(mediaID))
+		result = fmt.Sprintf("Download requested for Sonarr series with ID: %d", mediaID)
+	case "movie":
+		tags, err := m.requestTags(ctx, mediaType, requester)
+		if err != nil {
+			m.logger.Printf("Error resolving movie tags: %v", err)
+			return toolError("Failed to create tags in Radarr", err)
+		}
+
+		movie := Movie{
+			ID:    mediaID,
+			Title: mediaName,
+			Tags:  tags,
+		}
+
+		qualityProfileID, err := m.qualityProfileFor(ctx, mediaType, quality)
+		if err != nil {
+			m.logger.Printf("Error choosing quality profile: %v", err)
+			return toolError("Failed to choose a Radarr quality profile", err)
+		}
+		rootFolderPath, warning, err := m.rootFolderFor(ctx, mediaType)
+		if err != nil {
+			return m.rootFolderError("Radarr", err)
+		}
+		spaceWarning = warning
+
+		m.logger.Printf("Using quality profile ID: %d and root folder: %s",
+			qualityProfileID, rootFolderPath)
+
+		audit.Describe(ctx, "Radarr", mediaName, mediaID)
+		err = m.radarrClient.RequestMovieDownload(
+			ctx,
+			movie,
+			qualityProfileID,
+			rootFolderPath,
+		)
+
+		if err != nil {
+			m.logger.Printf("Error requesting movie download: %v", err)
+			audit.Fail(ctx, err)
+			return toolError("Failed to request download from Radarr", err)
+		}
+
+		m.logger.Printf("Successfully requested download for movie: %s", mediaName)
+		m.resourcesChanged(MoviesResourceURI, MovieResourceURIFor(mediaID))
+		result = fmt.Sprintf("Download requested for Radarr movie with ID: %d", mediaID)
+	default:
+		m.logger.Printf("Unsupported media type: %s", mediaType)
+		result = fmt.Sprintf("Unsupported media type: %s. Must be 'movie' or 'series'.", mediaType)
 	}
 
-	return server.ServerTool{
-		Tool:    tool,
-		Handler: handler,
+	if spaceWarning != "" {
+		result += "\n" + spaceWarning
 	}
+	return mcp.NewToolResultText(result)
 }
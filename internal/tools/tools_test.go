@@ -2,7 +2,26 @@ package tools
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/IdoKendo/mcparr/internal/approval"
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/events"
+	"github.com/IdoKendo/mcparr/internal/policy"
+	"github.com/IdoKendo/mcparr/pkg/client"
 )
 
 type MockConfig struct {
@@ -61,8 +80,1070 @@ func TestGetTools(t *testing.T) {
 
 	tools := mediaTools.Tools()
 
-	if len(tools) != 3 {
-		t.Errorf("Expected 3 tools, got %d", len(tools))
+	if len(tools) != 17 {
+		t.Errorf("Expected 17 tools, got %d", len(tools))
+	}
+}
+
+func TestGetResources(t *testing.T) {
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, &mockRadarrClient{})
+
+	if len(mediaTools.Resources()) != 5 {
+		t.Errorf("Expected 5 resources, got %d", len(mediaTools.Resources()))
+	}
+
+	if len(mediaTools.ResourceTemplates()) != 2 {
+		t.Errorf("Expected 2 resource templates, got %d", len(mediaTools.ResourceTemplates()))
+	}
+}
+
+func TestResourcesForEvent(t *testing.T) {
+	uris := ResourcesForEvent(events.Event{Source: "radarr", Type: events.TypeDownload, MediaID: 438631})
+
+	expected := map[string]bool{
+		EventsResourceURI:        true,
+		QueueResourceURI:         true,
+		CalendarResourceURI:      true,
+		MoviesResourceURI:        true,
+		"mcparr://movies/438631": true,
+	}
+
+	if len(uris) != len(expected) {
+		t.Fatalf("Expected %d URIs, got %v", len(expected), uris)
+	}
+
+	for _, uri := range uris {
+		if !expected[uri] {
+			t.Errorf("Unexpected URI '%s'", uri)
+		}
+	}
+}
+
+func TestWhatToWatchPrompt(t *testing.T) {
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, &mockRadarrClient{})
+
+	if len(mediaTools.Prompts()) != 4 {
+		t.Errorf("Expected 4 prompts, got %d", len(mediaTools.Prompts()))
+	}
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = map[string]string{"mood": "cozy", "genre": "comedy"}
+
+	result, err := mediaTools.WhatToWatch().Handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	if !strings.Contains(text, "cozy") || !strings.Contains(text, "genre 'comedy'") {
+		t.Errorf("Expected prompt to mention the mood and genre, got '%s'", text)
+	}
+
+	request.Params.Arguments = map[string]string{}
+	if _, err := mediaTools.WhatToWatch().Handler(context.Background(), request); err == nil {
+		t.Error("Expected an error when mood is missing")
+	}
+}
+
+type librarySonarrClient struct {
+	mockSonarrClient
+}
+
+func (m *librarySonarrClient) ListSeries(ctx context.Context) ([]Series, error) {
+	return []Series{
+		{ID: 371980, LibraryID: 1, Title: "Severance", Genres: []string{"Drama", "Mystery"}, Tags: []int{1}},
+		{ID: 73244, LibraryID: 2, Title: "The Office (US)", Genres: []string{"Comedy"}, Tags: []int{1}},
+	}, nil
+}
+
+func (m *librarySonarrClient) Queue(ctx context.Context) ([]QueueItem, error) {
+	return []QueueItem{
+		{Source: "sonarr", LibraryID: 1, Title: "Severance.S02E01.1080p"},
+		{Source: "sonarr", LibraryID: 2, Title: "The.Office.US.S01E01.1080p"},
+	}, nil
+}
+
+func (m *librarySonarrClient) Tags(ctx context.Context) ([]Tag, error) {
+	return []Tag{{ID: 1, Label: "kids"}, {ID: 2, Label: "anime"}}, nil
+}
+
+func TestCompletionIndex(t *testing.T) {
+	index := NewCompletionIndex(&librarySonarrClient{}, &mockRadarrClient{}, time.Minute)
+	ctx := context.Background()
+
+	completion, err := index.CompletePromptArgument(ctx, "what_to_watch", mcp.CompleteArgument{Name: "genre", Value: "my"}, mcp.CompleteContext{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(completion.Values) != 1 || completion.Values[0] != "Mystery" {
+		t.Errorf("Expected genre completion 'Mystery', got %v", completion.Values)
+	}
+
+	completion, _ = index.CompletePromptArgument(ctx, "clean_up_library", mcp.CompleteArgument{Name: "tag", Value: "KI"}, mcp.CompleteContext{})
+	if len(completion.Values) != 1 || completion.Values[0] != "kids" {
+		t.Errorf("Expected tag completion 'kids', got %v", completion.Values)
+	}
+
+	completion, _ = index.CompleteResourceArgument(ctx, "mcparr://series/{id}", mcp.CompleteArgument{Name: "id", Value: "office"}, mcp.CompleteContext{})
+	if len(completion.Values) != 1 || completion.Values[0] != "73244" {
+		t.Errorf("Expected series ID completion '73244', got %v", completion.Values)
+	}
+
+	if id, ok := index.TagID(ctx, "sonarr", "Anime"); !ok || id != 2 {
+		t.Errorf("Expected tag ID 2 for 'Anime', got %d", id)
+	}
+
+	index.SetContentRules(func(client string) policy.Content { return policy.Content{BlockedGenres: []string{"mystery"}} })
+	completion, _ = index.CompletePromptArgument(ctx, "what_to_watch", mcp.CompleteArgument{Name: "genre", Value: "my"}, mcp.CompleteContext{})
+	if len(completion.Values) != 0 {
+		t.Errorf("Expected the blocked genre not to be completed, got %v", completion.Values)
+	}
+	completion, _ = index.CompletePromptArgument(ctx, "what_to_watch", mcp.CompleteArgument{Name: "similar_to", Value: "sev"}, mcp.CompleteContext{})
+	if len(completion.Values) != 0 {
+		t.Errorf("Expected the blocked title not to be completed, got %v", completion.Values)
+	}
+}
+
+func TestContentRulesLibrary(t *testing.T) {
+	contentFor := func(client string) policy.Content { return policy.Content{BlockedGenres: []string{"Mystery"}} }
+	mediaTools := New(&MockConfig{}, &librarySonarrClient{}, &mockRadarrClient{}, WithContentRules(contentFor))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"type": "series", "tag": "kids"}
+	result, _ := mediaTools.LibraryByTag().Handler(context.Background(), request)
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "Severance") || !strings.Contains(text, "The Office (US)") {
+		t.Errorf("Expected only the allowed series to be listed, got '%s'", text)
+	}
+
+	contents, err := mediaTools.SeriesResource().Handler(context.Background(), mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text := contents[0].(mcp.TextResourceContents).Text; strings.Contains(text, "Severance") || !strings.Contains(text, "The Office (US)") {
+		t.Errorf("Expected only the allowed series in the resource, got '%s'", text)
+	}
+}
+
+func TestContentRulesQueueAndEvents(t *testing.T) {
+	contentFor := func(client string) policy.Content {
+		if client == "kids-tablet" {
+			return policy.Content{BlockedGenres: []string{"Mystery"}}
+		}
+		return policy.Content{}
+	}
+	eventLog := events.NewLog(10)
+	eventLog.Add(events.Event{Source: "sonarr", Type: events.TypeGrab, Title: "Severance S02E01", MediaID: 371980, Message: "Sonarr grabbed a release for Severance S02E01"})
+	eventLog.Add(events.Event{Source: "sonarr", Type: events.TypeGrab, Title: "The Office (US) S01E01", MediaID: 73244, Message: "Sonarr grabbed a release for The Office (US) S01E01"})
+	eventLog.Add(events.Event{Source: "mcparr", Type: events.TypeRequestPending, Title: "Severance", MediaID: 371980, Message: "Request #1 from cursor for Severance is waiting for approval"})
+	eventLog.Add(events.Event{Source: "sonarr", Type: events.TypeHealthIssue, Message: "Sonarr reported a health issue: Indexers are unavailable"})
+	mediaTools := New(&MockConfig{}, &librarySonarrClient{}, &mockRadarrClient{}, WithContentRules(contentFor), WithEvents(eventLog))
+	ctx := audit.WithUser(context.Background(), "kids-tablet")
+
+	result, _ := mediaTools.RecentEvents().Handler(ctx, mcp.CallToolRequest{})
+	text := result.Content[0].(mcp.TextContent).Text
+	if strings.Contains(text, "Severance") || !strings.Contains(text, "The Office (US)") || !strings.Contains(text, "health issue") {
+		t.Errorf("Expected only events about allowed titles or no title, got '%s'", text)
+	}
+
+	contents, err := mediaTools.EventsResource().Handler(ctx, mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text := contents[0].(mcp.TextResourceContents).Text; strings.Contains(text, "Severance") || !strings.Contains(text, "The Office (US)") {
+		t.Errorf("Expected only events about allowed titles in the resource, got '%s'", text)
+	}
+
+	contents, err = mediaTools.QueueResource().Handler(ctx, mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text := contents[0].(mcp.TextResourceContents).Text; strings.Contains(text, "Severance") || !strings.Contains(text, "The.Office") {
+		t.Errorf("Expected only downloads of allowed titles in the queue, got '%s'", text)
+	}
+
+	for _, e := range eventLog.Recent(0) {
+		blocked := e.Title != "" && strings.HasPrefix(e.Title, "Severance")
+		if got := mediaTools.EventAllowed(ctx, e); got == blocked {
+			t.Errorf("Expected pushing %q to be allowed: %t, got %t", e.Message, !blocked, got)
+		}
+		if !mediaTools.EventAllowed(context.Background(), e) {
+			t.Errorf("Expected %q to be pushed to a client without content rules", e.Message)
+		}
+	}
+}
+
+// unavailableRadarrClient fails to list movies and counts the attempts.
+type unavailableRadarrClient struct {
+	mockRadarrClient
+	calls atomic.Int32
+}
+
+func (m *unavailableRadarrClient) ListMovies(ctx context.Context) ([]Movie, error) {
+	m.calls.Add(1)
+	return nil, errors.New("connection refused")
+}
+
+func TestCompletionIndexFailingInstance(t *testing.T) {
+	radarr := &unavailableRadarrClient{}
+	index := NewCompletionIndex(&librarySonarrClient{}, radarr, time.Minute)
+	ctx := context.Background()
+
+	completion, _ := index.CompletePromptArgument(ctx, "what_to_watch", mcp.CompleteArgument{Name: "title", Value: "office"}, mcp.CompleteContext{})
+	if len(completion.Values) != 1 {
+		t.Errorf("Expected Sonarr titles despite Radarr failing, got %v", completion.Values)
+	}
+
+	for range 5 {
+		index.CompletePromptArgument(ctx, "what_to_watch", mcp.CompleteArgument{Name: "title", Value: "o"}, mcp.CompleteContext{})
+	}
+	if calls := radarr.calls.Load(); calls != 1 {
+		t.Errorf("Expected the failing instance to back off after one attempt, got %d", calls)
+	}
+}
+
+// rejectingRadarrClient fails every download request with err.
+type rejectingRadarrClient struct {
+	mockRadarrClient
+	err error
+}
+
+func (m *rejectingRadarrClient) RequestMovieDownload(ctx context.Context, movie Movie, qualityProfileID int, rootFolderPath string) error {
+	return m.err
+}
+
+func TestRequestDownloadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "already added",
+			err: &client.ValidationError{
+				APIError: client.APIError{Instance: "Radarr", StatusCode: 400},
+				Fields:   []client.FieldError{{Field: "TmdbId", Message: "This movie has already been added", Code: "MovieExistsValidator"}},
+			},
+			want: "Radarr rejected the request: This movie has already been added. It is already in the library",
+		},
+		{
+			name: "unauthorized",
+			err:  &client.UnauthorizedError{APIError: client.APIError{Instance: "Radarr", StatusCode: 401}},
+			want: "check the RADARR_API_KEY setting",
+		},
+		{
+			name: "unreachable",
+			err:  fmt.Errorf("failed to request movie download: %w", fmt.Errorf("Radarr is unreachable: %w", client.ErrCircuitOpen)),
+			want: "try again later",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mediaTools := New(&MockConfig{}, &mockSonarrClient{}, &rejectingRadarrClient{err: tt.err})
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"type": "movie", "name": "Arrival", "id": 329865}
+			result, err := mediaTools.RequestDownload().Handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if !result.IsError {
+				t.Error("Expected an error result")
+			}
+			text := result.Content[0].(mcp.TextContent).Text
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("Expected result to contain '%s', got '%s'", tt.want, text)
+			}
+		})
+	}
+}
+
+type recordingRadarrClient struct {
+	mockRadarrClient
+	downloaded []Movie
+}
+
+func (m *recordingRadarrClient) RequestMovieDownload(ctx context.Context, movie Movie, qualityProfileID int, rootFolderPath string) error {
+	movie.ProfileID = qualityProfileID
+	m.downloaded = append(m.downloaded, movie)
+	return nil
+}
+
+func TestApprovalQueue(t *testing.T) {
+	// The requester and the admin run in separate processes sharing the
+	// queue file.
+	path := filepath.Join(t.TempDir(), "requests.json")
+	requesterQueue, err := approval.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminQueue, err := approval.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requesterEvents, adminEvents := events.NewLog(10), events.NewLog(10)
+	radarrClient := &recordingRadarrClient{}
+
+	admins := []string{"claude-desktop"}
+	requester := New(&MockConfig{}, &mockSonarrClient{}, radarrClient,
+		WithEvents(requesterEvents), WithApprovals(requesterQueue, admins))
+	admin := New(&MockConfig{}, &mockSonarrClient{}, radarrClient,
+		WithEvents(adminEvents), WithApprovals(adminQueue, admins))
+	requesterCtx := audit.WithUser(context.Background(), "kids-tablet")
+	adminCtx := audit.WithUser(context.Background(), "claude-desktop")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"type": "movie", "name": "Arrival", "id": 329865}
+	result, _ := requester.RequestDownload().Handler(requesterCtx, request)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Request #1 for Arrival is waiting") {
+		t.Errorf("Expected the request to be held, got '%s'", text)
+	}
+	if len(radarrClient.downloaded) != 0 {
+		t.Errorf("Expected nothing to be downloaded before approval, got %v", radarrClient.downloaded)
+	}
+
+	result, _ = requester.ApproveRequest().Handler(requesterCtx, mcp.CallToolRequest{})
+	if !result.IsError {
+		t.Error("Expected non-admins to be refused")
+	}
+
+	result, _ = admin.ListPendingRequests().Handler(adminCtx, mcp.CallToolRequest{})
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "#1. ") || !strings.Contains(text, "movie Arrival") {
+		t.Errorf("Expected the pending request to be listed, got '%s'", text)
+	}
+
+	approve := mcp.CallToolRequest{}
+	approve.Params.Arguments = map[string]any{"request_id": 1}
+	result, _ = admin.ApproveRequest().Handler(adminCtx, approve)
+	if result.IsError {
+		t.Fatalf("Expected the request to be approved, got '%s'", result.Content[0].(mcp.TextContent).Text)
+	}
+	if len(radarrClient.downloaded) != 1 || radarrClient.downloaded[0].ID != 329865 {
+		t.Errorf("Expected Arrival to be downloaded, got %v", radarrClient.downloaded)
+	}
+
+	result, _ = admin.ApproveRequest().Handler(adminCtx, approve)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "already approved or denied") {
+		t.Errorf("Expected an approved request not to be approved again, got '%s'", text)
+	}
+	result, _ = admin.DenyRequest().Handler(adminCtx, approve)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "already approved or denied") {
+		t.Errorf("Expected a decided request to be refused, got '%s'", text)
+	}
+	if len(radarrClient.downloaded) != 1 {
+		t.Errorf("Expected Arrival to be downloaded once, got %v", radarrClient.downloaded)
+	}
+
+	recent := adminEvents.Recent(0)
+	if len(recent) != 1 || recent[0].Type != events.TypeRequestApproved {
+		t.Errorf("Expected an approved event, got %v", recent)
+	}
+
+	result, _ = requester.RecentEvents().Handler(requesterCtx, mcp.CallToolRequest{})
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Request #1 from kids-tablet for Arrival was approved by claude-desktop") {
+		t.Errorf("Expected the requester to be told of the approval, got '%s'", text)
+	}
+	result, _ = requester.MyQuota().Handler(requesterCtx, mcp.CallToolRequest{})
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "Updates to your requests") {
+		t.Errorf("Expected the requester to be told of the approval once, got '%s'", text)
+	}
+}
+
+type failingRadarrClient struct {
+	recordingRadarrClient
+}
+
+func (m *failingRadarrClient) RequestMovieDownload(ctx context.Context, movie Movie, qualityProfileID int, rootFolderPath string) error {
+	return errors.New("radarr is down")
+}
+
+func TestApproveRequestFailure(t *testing.T) {
+	queue, err := approval.Open(filepath.Join(t.TempDir(), "requests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.Add(approval.Request{User: "kids-tablet", Type: "movie", Title: "Arrival", MediaID: 329865})
+	admin := New(&MockConfig{}, &mockSonarrClient{}, &failingRadarrClient{},
+		WithApprovals(queue, []string{"unknown"}))
+
+	approve := mcp.CallToolRequest{}
+	approve.Params.Arguments = map[string]any{"request_id": 1}
+	result, _ := admin.ApproveRequest().Handler(context.Background(), approve)
+	if !result.IsError {
+		t.Errorf("Expected the failed download to fail the approval, got '%s'", result.Content[0].(mcp.TextContent).Text)
+	}
+
+	if pending, _ := queue.Pending(); len(pending) != 1 || pending[0].Status != approval.StatusPending {
+		t.Errorf("Expected the request to be pending again, got %+v", pending)
+	}
+}
+
+type taggingRadarrClient struct {
+	recordingRadarrClient
+	tags []Tag
+}
+
+func (m *taggingRadarrClient) Tags(ctx context.Context) ([]Tag, error) {
+	return m.tags, nil
+}
+
+func (m *taggingRadarrClient) CreateTag(ctx context.Context, label string) (Tag, error) {
+	tag := Tag{ID: len(m.tags) + 1, Label: label}
+	m.tags = append(m.tags, tag)
+	return tag, nil
+}
+
+func TestRequestDownloadTags(t *testing.T) {
+	if label := TagLabel("Claude Desktop (v2)"); label != "claude-desktop-v2" {
+		t.Errorf("Expected tag label 'claude-desktop-v2', got '%s'", label)
+	}
+
+	radarrClient := &taggingRadarrClient{tags: []Tag{{ID: 1, Label: "4k"}}}
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, radarrClient,
+		WithTags(TagSettings{Requester: true, Movies: []string{"4K"}}))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"type": "movie", "name": "Arrival", "id": 329865}
+	mediaTools.RequestDownload().Handler(context.Background(), request)
+	mediaTools.RequestDownload().Handler(context.Background(), request)
+
+	if len(radarrClient.tags) != 2 || radarrClient.tags[1].Label != "requested-by-unknown" {
+		t.Errorf("Expected the requester tag to be created once, got %v", radarrClient.tags)
+	}
+	if len(radarrClient.downloaded) != 2 || !slices.Equal(radarrClient.downloaded[1].Tags, []int{2, 1}) {
+		t.Errorf("Expected the movie to be tagged with tags 2 and 1, got %v", radarrClient.downloaded)
+	}
+}
+
+type ratedRadarrClient struct {
+	recordingRadarrClient
+}
+
+func (m *ratedRadarrClient) LookupMovie(ctx context.Context, name string) ([]Movie, error) {
+	movies := []Movie{
+		{ID: 329865, Title: "Arrival", Certification: "PG-13"},
+		{ID: 680, Title: "Pulp Fiction", Certification: "R"},
+	}
+	switch name {
+	case "tmdb:329865":
+		return movies[:1], nil
+	case "tmdb:680", "pulp fiction":
+		return movies[1:], nil
+	}
+	return movies, nil
+}
+
+func TestContentRules(t *testing.T) {
+	radarrClient := &ratedRadarrClient{}
+	contentFor := func(client string) policy.Content { return policy.Content{MaxCertification: "PG-13"} }
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, radarrClient, WithContentRules(contentFor))
+
+	search := mcp.CallToolRequest{}
+	search.Params.Arguments = map[string]any{"type": "movie", "name": "pulp fiction"}
+	result, _ := mediaTools.SearchMediaID().Handler(context.Background(), search)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "No matching movie") {
+		t.Errorf("Expected the R rated movie to be hidden, got '%s'", text)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"type": "movie", "name": "Pulp Fiction", "id": 680}
+	result, _ = mediaTools.RequestDownload().Handler(context.Background(), request)
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "rated R, above PG-13") {
+		t.Errorf("Expected the R rated movie to be refused, got '%s'", text)
+	}
+
+	request.Params.Arguments = map[string]any{"type": "movie", "name": "Arrival", "id": 329865}
+	result, _ = mediaTools.RequestDownload().Handler(context.Background(), request)
+	if result.IsError || len(radarrClient.downloaded) != 1 {
+		t.Errorf("Expected the PG-13 movie to be added, got '%s'", result.Content[0].(mcp.TextContent).Text)
+	}
+}
+
+type catalogRadarrClient struct {
+	recordingRadarrClient
+}
+
+func (m *catalogRadarrClient) LookupMovie(ctx context.Context, name string) ([]Movie, error) {
+	switch name {
+	case "arrival":
+		return []Movie{{ID: 329865, Title: "Arrival", Year: 2016}, {ID: 1, Title: "The Arrival", Year: 1996}}, nil
+	case "Dune":
+		return []Movie{{ID: 841, Title: "Dune", Year: 1984}, {ID: 438631, Title: "Dune", Year: 2021}}, nil
+	case "tmdb:603":
+		return []Movie{{ID: 603, Title: "The Matrix", Year: 1999, LibraryID: 5}}, nil
+	}
+	return nil, nil
+}
+
+func (m *catalogRadarrClient) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	return []QualityProfile{{ID: 1, Name: "Any"}, {ID: 4, Name: "HD - 720p/1080p"}, {ID: 5, Name: "Ultra-HD"}, {ID: 6, Name: "HD-1080p"}}, nil
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want Query
+	}{
+		{"Dune", Query{Title: "Dune", Text: "Dune"}},
+		{"Dune 2021 in 4K", Query{Title: "Dune", Text: "Dune 2021", Year: 2021, Quality: "2160p"}},
+		{"Dune (2021)", Query{Title: "Dune", Text: "Dune (2021)", Year: 2021}},
+		{"the office us s3", Query{Title: "the office", Text: "the office us", Country: "US", Season: 3, countryWord: "us"}},
+		{"The Office (UK) S02E05", Query{Title: "The Office", Text: "The Office (UK)", Country: "UK", Season: 2, Episode: 5, countryWord: "(UK)"}},
+		{"This Is Us", Query{Title: "This Is", Text: "This Is Us", Country: "US", countryWord: "Us"}},
+		{"fargo 2x04 1080p", Query{Title: "fargo", Text: "fargo", Season: 2, Episode: 4, Quality: "1080p"}},
+		{"breaking bad season 5 episode 14", Query{Title: "breaking bad", Text: "breaking bad", Season: 5, Episode: 14}},
+		{"Arrival in full hd", Query{Title: "Arrival", Text: "Arrival", Quality: "1080p"}},
+		{"1917", Query{Title: "1917", Text: "1917"}},
+		{"Us", Query{Title: "Us", Text: "Us"}},
+		{"Blade Runner 2049", Query{Title: "Blade Runner 2049", Text: "Blade Runner 2049"}},
+		{"tt0816692", Query{Title: "tt0816692", Text: "tt0816692"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := ParseQuery(tt.text); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// countrySonarrClient has series whose titles end in a country, and one
+// from a country whose title starts like them.
+type countrySonarrClient struct {
+	mockSonarrClient
+}
+
+func (m *countrySonarrClient) LookupSeries(ctx context.Context, name string) ([]Series, error) {
+	switch strings.ToLower(name) {
+	case "this is":
+		return []Series{{ID: 1, Title: "This Is Going to Hurt (US)", Year: 2023}, {ID: 2, Title: "This Is England '86", Year: 2010}}, nil
+	case "this is us":
+		return []Series{{ID: 311714, Title: "This Is Us", Year: 2016}}, nil
+	case "the office":
+		return []Series{{ID: 73244, Title: "The Office (US)", Year: 2005}, {ID: 78107, Title: "The Office", Year: 2001}}, nil
+	}
+	return nil, nil
+}
+
+func TestLookupCandidatesCountry(t *testing.T) {
+	mediaTools := New(&MockConfig{}, &countrySonarrClient{}, &mockRadarrClient{})
+
+	tests := []struct {
+		query   string
+		wantID  int
+		wantFor string
+	}{
+		{"This Is Us", 311714, ""},
+		{"this is us 2016", 311714, " (read as 'this is us' with 2016)"},
+		{"the office us", 73244, " (read as 'the office' with US)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			candidates, q, err := mediaTools.lookupCandidates(context.Background(), "series", tt.query)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(candidates) != 1 || candidates[0].ID != tt.wantID {
+				t.Errorf("Expected only series %d, got %v", tt.wantID, candidates)
+			}
+			if got := q.describe(); got != tt.wantFor {
+				t.Errorf("Expected the query to be read as %q, got %q", tt.wantFor, got)
+			}
+		})
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"name": "This Is Us"}
+	result, _ := mediaTools.Search().Handler(context.Background(), request)
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "1. This Is Us (2016) (ID: 311714) - series") || strings.Contains(text, "read as") {
+		t.Errorf("Expected This Is Us to be searched as a title, got '%s'", text)
+	}
+}
+
+func TestRankResultsCountry(t *testing.T) {
+	results := []searchResult{
+		{candidate: candidate{Title: "This Is Going to Hurt"}, mediaType: "series", position: 0},
+		{candidate: candidate{Title: "This Is Us"}, mediaType: "series", position: 1},
+	}
+	ranked := rankResults(ParseQuery("This Is Us"), results)
+	if ranked[0].Title != "This Is Us" {
+		t.Errorf("Expected the title with the country word to rank first, got %v", ranked)
+	}
+}
+
+func TestMatchQualityProfile(t *testing.T) {
+	profiles := []QualityProfile{{ID: 1, Name: "Any"}, {ID: 4, Name: "HD - 720p/1080p"}, {ID: 5, Name: "Ultra-HD"}, {ID: 6, Name: "HD-1080p"}, {ID: 2, Name: "SD"}}
+
+	tests := []struct {
+		quality string
+		wantID  int
+		wantOK  bool
+	}{
+		{"2160p", 5, true},
+		{"1080p", 6, true},
+		{"720p", 4, true},
+		{"480p", 2, true},
+	}
+
+	for _, tt := range tests {
+		profile, ok := matchQualityProfile(profiles, tt.quality)
+		if ok != tt.wantOK || profile.ID != tt.wantID {
+			t.Errorf("Expected profile %d for %s, got %d (found: %v)", tt.wantID, tt.quality, profile.ID, ok)
+		}
+	}
+
+	if _, ok := matchQualityProfile(profiles[:1], "2160p"); ok {
+		t.Errorf("Expected no profile for 2160p among %v", profiles[:1])
+	}
+}
+
+func TestRequestDownloadBatch(t *testing.T) {
+	radarrClient := &catalogRadarrClient{}
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, radarrClient)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"movies": []any{"arrival", "Dune", "tmdb:603", "Nonexistent"},
+		"series": []any{"Severance"},
+	}
+	result, err := mediaTools.RequestDownloadBatch().Handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"Processed 5 titles: 1 added, 1 already in the library, 1 ambiguous, 2 not found.",
+		`1. movie "arrival": added - Arrival (2016) (ID: 329865)`,
+		`2. movie "Dune": ambiguous - did you mean Dune (1984) (ID: 841), or Dune (2021) (ID: 438631)?`,
+		`3. movie "tmdb:603": already in the library - The Matrix (1999) (ID: 603)`,
+		`5. series "Severance": not found`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected report to contain '%s', got '%s'", want, text)
+		}
+	}
+
+	if len(radarrClient.downloaded) != 1 || radarrClient.downloaded[0].ID != 329865 {
+		t.Errorf("Expected only Arrival to be downloaded, got %v", radarrClient.downloaded)
+	}
+
+	trail := &memoryAuditTrail{}
+	handler := audit.Middleware(trail, AuditedTools, log.Default())(mediaTools.RequestDownloadBatch().Handler)
+	request.Params.Name = "request_download_batch"
+	request.Params.Arguments = map[string]any{"movies": []any{"Dune 2021 in 4K"}}
+	result, _ = handler(context.Background(), request)
+	text = result.Content[0].(mcp.TextContent).Text
+	if len(trail.entries) != 2 || trail.entries[0].Action != audit.ActionSearch || trail.entries[1].Action != audit.ActionAdd {
+		t.Errorf("Expected the lookup and the add to be audited, got %+v", trail.entries)
+	}
+	if !strings.Contains(text, "added - Dune (2021) (ID: 438631)") {
+		t.Errorf("Expected the 2021 Dune to be added, got '%s'", text)
+	}
+	if added := radarrClient.downloaded[len(radarrClient.downloaded)-1]; added.ID != 438631 || added.ProfileID != 5 {
+		t.Errorf("Expected Dune (2021) to be added with the Ultra-HD profile, got %+v", added)
+	}
+	if !strings.Contains(text, `1. movie "Dune 2021 in 4K" (read as 'Dune' with 2021, 2160p): added`) {
+		t.Errorf("Expected the report to show how the title was read, got '%s'", text)
+	}
+
+	search := mcp.CallToolRequest{}
+	search.Params.Arguments = map[string]any{"type": "movie", "name": "Dune 2021"}
+	result, _ = mediaTools.SearchMediaID().Handler(context.Background(), search)
+	if text := result.Content[0].(mcp.TextContent).Text; text != "Found Radarr movie with ID: 438631" {
+		t.Errorf("Expected search_media_id to look up the parsed title and year, got '%s'", text)
+	}
+}
+
+type collectionRadarrClient struct {
+	recordingRadarrClient
+	updated []Collection
+}
+
+func (m *collectionRadarrClient) LookupMovie(ctx context.Context, name string) ([]Movie, error) {
+	return []Movie{{ID: 438631, Title: "Dune", Year: 2021, Collection: &MovieCollection{Title: "Dune Collection", TmdbID: 726871}}}, nil
+}
+
+func (m *collectionRadarrClient) Collections(ctx context.Context, tmdbID int) ([]Collection, error) {
+	return []Collection{{
+		ID:     3,
+		Title:  "Dune Collection",
+		TmdbID: 726871,
+		Movies: []CollectionMovie{
+			{ID: 438631, Title: "Dune", Year: 2021, Status: "released", InLibrary: true},
+			{ID: 693134, Title: "Dune: Part Two", Year: 2024, Status: "released"},
+			{ID: 1170608, Title: "Dune: Part Three", Year: 2026, Status: "announced"},
+		},
+	}}, nil
+}
+
+func (m *collectionRadarrClient) UpdateCollection(ctx context.Context, collection Collection) error {
+	m.updated = append(m.updated, collection)
+	return nil
+}
+
+func TestCollection(t *testing.T) {
+	radarrClient := &collectionRadarrClient{}
+	mediaTools := New(&MockConfig{moviesRootPath: "/test/movies", defaultQualityProfileID: 10}, &mockSonarrClient{}, radarrClient)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"name": "Dune: Part Two"}
+	result, _ := mediaTools.CollectionTool().Handler(context.Background(), request)
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"3 movies: 1 owned, 1 missing, 1 unreleased.",
+		"2. Dune: Part Two (2024) (ID: 693134): missing",
+		"3. Dune: Part Three (2026) (ID: 1170608): unreleased",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected result to contain '%s', got '%s'", want, text)
+		}
+	}
+	if len(radarrClient.downloaded) != 0 {
+		t.Errorf("Expected nothing to be added without add_missing, got %v", radarrClient.downloaded)
+	}
+
+	request.Params.Arguments = map[string]any{"name": "dune collection", "add_missing": true, "monitor": true}
+	result, _ = mediaTools.CollectionTool().Handler(context.Background(), request)
+	text = result.Content[0].(mcp.TextContent).Text
+	if len(radarrClient.downloaded) != 1 || radarrClient.downloaded[0].ID != 693134 {
+		t.Errorf("Expected only the missing released movie to be added, got %v", radarrClient.downloaded)
+	}
+	if !strings.Contains(text, "missing - added") {
+		t.Errorf("Expected the added movie to be reported, got '%s'", text)
+	}
+	if len(radarrClient.updated) != 1 || !radarrClient.updated[0].Monitored || radarrClient.updated[0].RootFolderPath != "/test/movies" {
+		t.Errorf("Expected the collection to be monitored with the default root folder, got %+v", radarrClient.updated)
+	}
+}
+
+type officeSonarrClient struct {
+	mockSonarrClient
+}
+
+func (m *officeSonarrClient) LookupSeries(ctx context.Context, name string) ([]Series, error) {
+	return []Series{
+		{ID: 73244, Title: "The Office (US)", Year: 2005, LibraryID: 2},
+		{ID: 78107, Title: "The Office", Year: 2001},
+		{ID: 78107, Title: "The Office", Year: 2001},
+	}, nil
+}
+
+type officeRadarrClient struct {
+	mockRadarrClient
+}
+
+func (m *officeRadarrClient) LookupMovie(ctx context.Context, name string) ([]Movie, error) {
+	return []Movie{{ID: 1, Title: "Office Space", Year: 1999}, {ID: 2, Title: "The Office", Year: 2013}}, nil
+}
+
+func TestSearch(t *testing.T) {
+	mediaTools := New(&MockConfig{}, &officeSonarrClient{}, &officeRadarrClient{})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"name": "the office"}
+	result, err := mediaTools.Search().Handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"Found 4 results for 'the office':",
+		"1. The Office (2001) (ID: 78107) - series in Sonarr\n",
+		"2. The Office (2013) (ID: 2) - movie in Radarr\n",
+		"3. The Office (US) (2005) (ID: 73244) - series in Sonarr, already in the library",
+		"4. Office Space (1999) (ID: 1) - movie in Radarr",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected result to contain '%s', got '%s'", want, text)
+		}
+	}
+
+	request.Params.Arguments = map[string]any{"name": "the office s2"}
+	result, _ = mediaTools.Search().Handler(context.Background(), request)
+	text = result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "for 'the office s2' (read as 'the office' with season 2):\n1. The Office (2001)") {
+		t.Errorf("Expected results ranked against the parsed title, got '%s'", text)
+	}
+}
+
+type profileRadarrClient struct {
+	catalogRadarrClient
+	editedIDs []int
+	profileID int
+}
+
+func (m *profileRadarrClient) ListMovies(ctx context.Context) ([]Movie, error) {
+	return []Movie{
+		{LibraryID: 1, Title: "Oppenheimer", Year: 2023, ProfileID: 6},
+		{LibraryID: 2, Title: "Toy Story", Year: 1995, Genres: []string{"Animation"}, ProfileID: 6},
+		{LibraryID: 3, Title: "Up", Year: 2009, Genres: []string{"Animation", "Comedy"}, ProfileID: 6},
+		{LibraryID: 4, Title: "Coco", Year: 2017, Genres: []string{"animation"}, ProfileID: 4},
+	}, nil
+}
+
+func (m *profileRadarrClient) EditMovies(ctx context.Context, libraryIDs []int, qualityProfileID int) error {
+	m.editedIDs, m.profileID = libraryIDs, qualityProfileID
+	return nil
+}
+
+func TestSetQualityProfile(t *testing.T) {
+	radarrClient := &profileRadarrClient{}
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, radarrClient)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"type": "movie", "profile": "4K", "title": "oppenheimer"}
+	result, _ := mediaTools.SetQualityProfile().Handler(context.Background(), request)
+	if result.IsError || !slices.Equal(radarrClient.editedIDs, []int{1}) || radarrClient.profileID != 5 {
+		t.Errorf("Expected Oppenheimer to move to Ultra-HD, got %v to %d: '%s'",
+			radarrClient.editedIDs, radarrClient.profileID, result.Content[0].(mcp.TextContent).Text)
+	}
+
+	request.Params.Arguments = map[string]any{"type": "movie", "profile": "720p", "genre": "Animation"}
+	result, _ = mediaTools.SetQualityProfile().Handler(context.Background(), request)
+	text := result.Content[0].(mcp.TextContent).Text
+	if !slices.Equal(radarrClient.editedIDs, []int{2, 3}) || radarrClient.profileID != 4 {
+		t.Errorf("Expected the animated movies to move to profile 4, got %v to %d", radarrClient.editedIDs, radarrClient.profileID)
+	}
+	if !strings.Contains(text, "Moved 2 movies to the HD - 720p/1080p quality profile") || !strings.Contains(text, "1 were already in that profile") {
+		t.Errorf("Expected the change to be reported, got '%s'", text)
+	}
+
+	request.Params.Arguments = map[string]any{"type": "movie", "profile": "8K", "title": "Up"}
+	result, _ = mediaTools.SetQualityProfile().Handler(context.Background(), request)
+	if !result.IsError {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+type storageRadarrClient struct {
+	mockRadarrClient
+	rootFolderPaths []string
+}
+
+func (m *storageRadarrClient) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	return []RootFolder{
+		{ID: 1, Path: "/movies", Accessible: true, FreeSpace: 20 << 30},
+		{ID: 2, Path: "/mnt/big/movies/", Accessible: true, FreeSpace: 3 << 40},
+		{ID: 3, Path: "/mnt/gone", Accessible: false},
+	}, nil
+}
+
+func (m *storageRadarrClient) DiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	return []DiskSpace{
+		{Path: "/", FreeSpace: 20 << 30, TotalSpace: 100 << 30},
+		{Path: "/mnt/big", FreeSpace: 3 << 40, TotalSpace: 4 << 40},
+	}, nil
+}
+
+func (m *storageRadarrClient) RequestMovieDownload(ctx context.Context, movie Movie, qualityProfileID int, rootFolderPath string) error {
+	m.rootFolderPaths = append(m.rootFolderPaths, rootFolderPath)
+	return nil
+}
+
+func TestDiskSpace(t *testing.T) {
+	radarrClient := &storageRadarrClient{}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"type": "movie", "name": "Arrival", "id": 329865}
+
+	mediaTools := New(&MockConfig{moviesRootPath: AutoRootFolder}, &mockSonarrClient{}, radarrClient)
+	mediaTools.RequestDownload().Handler(context.Background(), request)
+	if len(radarrClient.rootFolderPaths) != 1 || radarrClient.rootFolderPaths[0] != "/mnt/big/movies/" {
+		t.Errorf("Expected the movie to go to the folder with the most free space, got %v", radarrClient.rootFolderPaths)
+	}
+
+	mediaTools = New(&MockConfig{moviesRootPath: "/movies"}, &mockSonarrClient{}, radarrClient, WithSpaceCheck(SpaceSettings{MinFree: 50 << 30}))
+	result, _ := mediaTools.RequestDownload().Handler(context.Background(), request)
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.Contains(text, "Warning: /movies has 20.0 GB free, below the minimum of 50.0 GB") {
+		t.Errorf("Expected a low space warning, got '%s'", text)
+	}
+
+	mediaTools = New(&MockConfig{moviesRootPath: "/movies"}, &mockSonarrClient{}, radarrClient, WithSpaceCheck(SpaceSettings{MinFree: 50 << 30, Refuse: true}))
+	result, _ = mediaTools.RequestDownload().Handler(context.Background(), request)
+	if !result.IsError || len(radarrClient.rootFolderPaths) != 2 {
+		t.Errorf("Expected the add to be refused, got '%s'", result.Content[0].(mcp.TextContent).Text)
+	}
+
+	request.Params.Arguments = map[string]any{"type": "movie"}
+	result, _ = mediaTools.DiskSpaceTool().Handler(context.Background(), request)
+	text = result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"- /movies: 20.0 GB free of 100.0 GB (80% used), below the minimum of 50.0 GB (new items go here)",
+		"- /mnt/big/movies/: 3.0 TB free of 4.0 TB (25% used)\n",
+		"- /mnt/gone: not accessible",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected result to contain '%s', got '%s'", want, text)
+		}
+	}
+}
+
+func TestRootFolderForLiveFreeSpace(t *testing.T) {
+	var bigFree atomic.Int64
+	bigFree.Store(3 << 40)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id":1,"path":"/movies","accessible":true,"freeSpace":%d},{"id":2,"path":"/mnt/big/movies","accessible":true,"freeSpace":%d}]`,
+			int64(100<<30), bigFree.Load())
+	}))
+	defer server.Close()
+
+	radarrClient := NewRadarrClientAdapter(client.NewRadarrClient(server.URL, "test-api-key", client.WithCache(client.DefaultCacheTTLs())))
+	mediaTools := New(&MockConfig{moviesRootPath: AutoRootFolder}, &mockSonarrClient{}, radarrClient,
+		WithSpaceCheck(SpaceSettings{MinFree: 50 << 30, Refuse: true}))
+	ctx := context.Background()
+
+	if path, _, err := mediaTools.rootFolderFor(ctx, "movie"); err != nil || path != "/mnt/big/movies" {
+		t.Fatalf("Expected the folder with the most free space, got %q and %v", path, err)
+	}
+
+	// The big disk filled up since the last add.
+	bigFree.Store(10 << 30)
+	if path, _, err := mediaTools.rootFolderFor(ctx, "movie"); err != nil || path != "/movies" {
+		t.Errorf("Expected the choice to follow the current free space, got %q and %v", path, err)
+	}
+
+	mediaTools = New(&MockConfig{moviesRootPath: "/mnt/big/movies"}, &mockSonarrClient{}, radarrClient,
+		WithSpaceCheck(SpaceSettings{MinFree: 50 << 30, Refuse: true}))
+	if _, _, err := mediaTools.rootFolderFor(ctx, "movie"); !errors.Is(err, errLowSpace) {
+		t.Errorf("Expected the add to be refused on the current free space, got %v", err)
+	}
+}
+
+type memoryAuditTrail struct {
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+func (m *memoryAuditTrail) Record(e audit.Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *memoryAuditTrail) Query(filter audit.Filter) ([]audit.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var matching []audit.Entry
+	for _, e := range m.entries {
+		if filter.Matches(e) {
+			matching = append(matching, e)
+		}
+	}
+	return matching, nil
+}
+
+func TestAuditLogAccess(t *testing.T) {
+	trail := &memoryAuditTrail{entries: []audit.Entry{
+		{Time: time.Now(), User: "kids-tablet", Tool: "request_download", Action: audit.ActionAdd, Title: "Arrival", Outcome: audit.OutcomePending},
+		{Time: time.Now(), User: "cursor", Tool: "request_download", Action: audit.ActionAdd, Title: "Severance", Outcome: audit.OutcomePending},
+	}}
+	queue, err := approval.Open(filepath.Join(t.TempDir(), "requests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, &mockRadarrClient{},
+		WithAuditTrail(trail), WithApprovals(queue, []string{"claude-desktop"}))
+
+	request := mcp.CallToolRequest{}
+	result, _ := mediaTools.AuditLog().Handler(audit.WithUser(context.Background(), "kids-tablet"), request)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Arrival") || strings.Contains(text, "Severance") {
+		t.Errorf("Expected a non-admin to only see its own actions, got '%s'", text)
+	}
+
+	request.Params.Arguments = map[string]any{"user": "cursor"}
+	result, _ = mediaTools.AuditLog().Handler(audit.WithUser(context.Background(), "kids-tablet"), request)
+	if !result.IsError {
+		t.Errorf("Expected a non-admin to be refused the actions of others, got '%s'", result.Content[0].(mcp.TextContent).Text)
+	}
+
+	result, _ = mediaTools.AuditLog().Handler(audit.WithUser(context.Background(), "claude-desktop"), request)
+	if text := result.Content[0].(mcp.TextContent).Text; result.IsError || !strings.Contains(text, "Severance") {
+		t.Errorf("Expected an admin to see the actions of others, got '%s'", text)
+	}
+}
+
+func TestRequestQuota(t *testing.T) {
+	trail := &memoryAuditTrail{entries: []audit.Entry{
+		{Time: time.Now().Add(-time.Hour), User: "unknown", Action: audit.ActionAdd, Instance: "Radarr", Outcome: audit.OutcomeSuccess},
+		{Time: time.Now().Add(-time.Hour), User: "unknown", Action: audit.ActionAdd, Instance: "Radarr", Outcome: audit.OutcomeFailure},
+		{Time: time.Now().Add(-8 * 24 * time.Hour), User: "unknown", Action: audit.ActionAdd, Instance: "Radarr", Outcome: audit.OutcomeSuccess},
+		{Time: time.Now().Add(-time.Hour), User: "cursor", Action: audit.ActionAdd, Instance: "Radarr", Outcome: audit.OutcomeSuccess},
+		{Time: time.Now().Add(-time.Hour), User: "unknown", Tool: "approve_request", Action: audit.ActionAdd, Instance: "Sonarr", Outcome: audit.OutcomeSuccess},
+	}}
+	quotaFor := func(client string) policy.Quota { return policy.Quota{Movies: 2, Series: 1} }
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, &mockRadarrClient{},
+		WithAuditTrail(trail), WithQuotas(quotaFor, 7*24*time.Hour))
+	handler := audit.Middleware(trail, AuditedTools, log.Default())(mediaTools.RequestDownload().Handler)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "request_download"
+	request.Params.Arguments = map[string]any{"type": "movie", "name": "Arrival", "id": 329865}
+	result, _ := handler(context.Background(), request)
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Quota: 0 of 2 movies left over the last 7 days.") {
+		t.Errorf("Expected the remaining quota to be reported, got '%s'", text)
+	}
+
+	result, _ = handler(context.Background(), request)
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "quota of 2 movies per 7 days is used up") {
+		t.Errorf("Expected the request to be refused, got '%s'", text)
+	}
+
+	result, _ = mediaTools.MyQuota().Handler(context.Background(), mcp.CallToolRequest{})
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Movies: 2 of 2 requested, 0 left") || !strings.Contains(text, "Series: 0 of 1 requested, 1 left") {
+		t.Errorf("Expected my_quota to report usage without approvals of other requests, got '%s'", text)
+	}
+}
+
+func TestRequestQuotaReservation(t *testing.T) {
+	trail := &memoryAuditTrail{}
+	quotaFor := func(client string) policy.Quota { return policy.Quota{Movies: 1} }
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, &mockRadarrClient{},
+		WithAuditTrail(trail), WithQuotas(quotaFor, 24*time.Hour))
+
+	// The first request is still being added, so it is not audited yet.
+	_, release, denied := mediaTools.checkQuota(context.Background(), "movie", 329865)
+	if denied != nil {
+		t.Fatalf("Expected the first request to pass, got '%s'", denied.Content[0].(mcp.TextContent).Text)
+	}
+	if _, _, denied := mediaTools.checkQuota(context.Background(), "movie", 438631); denied == nil {
+		t.Error("Expected a concurrent request to see the reserved quota")
+	}
+
+	release()
+	if _, _, denied := mediaTools.checkQuota(context.Background(), "movie", 438631); denied != nil {
+		t.Errorf("Expected a released reservation to free the quota, got '%s'", denied.Content[0].(mcp.TextContent).Text)
+	}
+}
+
+func TestRequestQuotaDenied(t *testing.T) {
+	queue, err := approval.Open(filepath.Join(t.TempDir(), "requests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trail := &memoryAuditTrail{}
+	quotaFor := func(client string) policy.Quota { return policy.Quota{Movies: 1} }
+	mediaTools := New(&MockConfig{}, &mockSonarrClient{}, &mockRadarrClient{},
+		WithAuditTrail(trail), WithQuotas(quotaFor, 24*time.Hour), WithApprovals(queue, []string{"claude-desktop"}))
+	handler := audit.Middleware(trail, AuditedTools, log.Default())(mediaTools.RequestDownload().Handler)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "request_download"
+	request.Params.Arguments = map[string]any{"type": "movie", "name": "Arrival", "id": 329865}
+	handler(context.Background(), request)
+	if result, _ := handler(context.Background(), request); !result.IsError {
+		t.Error("Expected the pending request to use up the quota")
+	}
+
+	queue.Decide(1, approval.StatusDenied, "claude-desktop", "")
+	if result, _ := handler(context.Background(), request); result.IsError {
+		t.Errorf("Expected the denied request to free the quota, got '%s'", result.Content[0].(mcp.TextContent).Text)
 	}
 }
 
@@ -84,6 +1165,22 @@ func (m *mockSonarrClient) SearchSeriesByGenre(ctx context.Context, genre string
 	return []Series{}, nil
 }
 
+func (m *mockSonarrClient) ListSeries(ctx context.Context) ([]Series, error) {
+	return []Series{}, nil
+}
+
+func (m *mockSonarrClient) GetSeries(ctx context.Context, tvdbID int) (Series, error) {
+	return Series{ID: tvdbID}, nil
+}
+
+func (m *mockSonarrClient) Calendar(ctx context.Context, start, end time.Time) ([]Episode, error) {
+	return []Episode{}, nil
+}
+
+func (m *mockSonarrClient) Queue(ctx context.Context) ([]QueueItem, error) {
+	return []QueueItem{}, nil
+}
+
 type mockRadarrClient struct{}
 
 func (m *mockRadarrClient) LookupMovie(ctx context.Context, name string) ([]Movie, error) {
@@ -97,3 +1194,103 @@ func (m *mockRadarrClient) RequestMovieDownload(ctx context.Context, movie Movie
 func (m *mockRadarrClient) SearchMoviesByGenre(ctx context.Context, genre string, similarTo string, limit int) ([]Movie, error) {
 	return []Movie{}, nil
 }
+
+func (m *mockRadarrClient) RequestMovieDelete(ctx context.Context, movie Movie) error {
+	return nil
+}
+
+func (m *mockRadarrClient) ListMovies(ctx context.Context) ([]Movie, error) {
+	return []Movie{}, nil
+}
+
+func (m *mockRadarrClient) GetMovie(ctx context.Context, tmdbID int) (Movie, error) {
+	return Movie{ID: tmdbID}, nil
+}
+
+func (m *mockRadarrClient) Calendar(ctx context.Context, start, end time.Time) ([]Movie, error) {
+	return []Movie{}, nil
+}
+
+func (m *mockRadarrClient) Queue(ctx context.Context) ([]QueueItem, error) {
+	return []QueueItem{}, nil
+}
+
+func (m *mockSonarrClient) Tags(ctx context.Context) ([]Tag, error) {
+	return []Tag{}, nil
+}
+
+func (m *mockSonarrClient) CreateTag(ctx context.Context, label string) (Tag, error) {
+	return Tag{Label: label}, nil
+}
+
+func (m *mockSonarrClient) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	return []QualityProfile{}, nil
+}
+
+func (m *mockSonarrClient) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	return []RootFolder{}, nil
+}
+
+func (m *mockRadarrClient) Tags(ctx context.Context) ([]Tag, error) {
+	return []Tag{}, nil
+}
+
+func (m *mockRadarrClient) CreateTag(ctx context.Context, label string) (Tag, error) {
+	return Tag{Label: label}, nil
+}
+
+func (m *mockRadarrClient) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	return []QualityProfile{}, nil
+}
+
+func (m *mockRadarrClient) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	return []RootFolder{}, nil
+}
+
+func (m *mockRadarrClient) Collections(ctx context.Context, tmdbID int) ([]Collection, error) {
+	return []Collection{}, nil
+}
+
+func (m *mockRadarrClient) UpdateCollection(ctx context.Context, collection Collection) error {
+	return nil
+}
+
+func (m *mockSonarrClient) EditSeries(ctx context.Context, libraryIDs []int, qualityProfileID int) error {
+	return nil
+}
+
+func (m *mockRadarrClient) EditMovies(ctx context.Context, libraryIDs []int, qualityProfileID int) error {
+	return nil
+}
+
+func (m *mockSonarrClient) DiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	return []DiskSpace{}, nil
+}
+
+func (m *mockRadarrClient) DiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	return []DiskSpace{}, nil
+}
+
+func TestMutates(t *testing.T) {
+	tests := []struct {
+		tool      string
+		arguments map[string]any
+		want      bool
+	}{
+		{"request_download", map[string]any{"name": "Dune"}, true},
+		{"search", map[string]any{"name": "Dune"}, false},
+		{"recent_events", nil, false},
+		{"collection", map[string]any{"name": "Dune"}, false},
+		{"collection", map[string]any{"name": "Dune", "add_missing": true}, true},
+		{"collection", map[string]any{"name": "Dune", "monitor": true}, true},
+	}
+
+	for _, tt := range tests {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tt.tool
+		request.Params.Arguments = tt.arguments
+		if got := Mutates(request); got != tt.want {
+			t.Errorf("Expected Mutates(%s %v) to be %v, got %v", tt.tool, tt.arguments, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/IdoKendo/mcparr/internal/events"
+)
+
+// maxPayloadSize caps the size of an accepted webhook body.
+const maxPayloadSize = 1 << 20
+
+// Handler receives Sonarr and Radarr Connect webhooks and records them in an
+// event log.
+type Handler struct {
+	secret string
+	events *events.Log
+	logger *log.Logger
+}
+
+// payload holds the fields of a Connect webhook that mcparr cares about.
+type payload struct {
+	EventType string `json:"eventType"`
+	IsUpgrade bool   `json:"isUpgrade"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Series    *struct {
+		Title  string `json:"title"`
+		TvdbID int    `json:"tvdbId"`
+	} `json:"series"`
+	Movie *struct {
+		Title  string `json:"title"`
+		Year   int    `json:"year"`
+		TmdbID int    `json:"tmdbId"`
+	} `json:"movie"`
+	Episodes []struct {
+		SeasonNumber  int `json:"seasonNumber"`
+		EpisodeNumber int `json:"episodeNumber"`
+	} `json:"episodes"`
+}
+
+// NewHandler creates a new Handler that only accepts requests carrying secret.
+func NewHandler(secret string, eventLog *events.Log) *Handler {
+	return &Handler{
+		secret: secret,
+		events: eventLog,
+		logger: log.Default(),
+	}
+}
+
+// Routes registers the webhook endpoints on mux.
+func (h *Handler) Routes(mux *http.ServeMux) {
+	mux.Handle("POST /webhook/sonarr", h.receive("sonarr"))
+	mux.Handle("POST /webhook/radarr", h.receive("radarr"))
+}
+
+func (h *Handler) receive(source string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.authorized(r) {
+			h.logger.Printf("Rejected %s webhook from %s: invalid secret", source, r.RemoteAddr)
+			http.Error(w, "invalid secret", http.StatusUnauthorized)
+			return
+		}
+
+		var p payload
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPayloadSize)).Decode(&p); err != nil {
+			h.logger.Printf("Error decoding %s webhook: %v", source, err)
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		event := h.events.Add(toEvent(source, p))
+		h.logger.Printf("Received %s webhook: %s", source, event.Message)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authorized reports whether the request carries the shared secret, either as
+// the password of basic auth (as configured in the arr Connect settings), the
+// X-Mcparr-Secret header, or the secret query parameter.
+func (h *Handler) authorized(r *http.Request) bool {
+	candidate := r.Header.Get("X-Mcparr-Secret")
+	if _, password, ok := r.BasicAuth(); ok {
+		candidate = password
+	}
+	if candidate == "" {
+		candidate = r.URL.Query().Get("secret")
+	}
+
+	return candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(h.secret)) == 1
+}
+
+func toEvent(source string, p payload) events.Event {
+	event := events.Event{
+		Source: source,
+		Type:   eventType(p),
+	}
+
+	switch {
+	case p.Series != nil:
+		event.Title = p.Series.Title
+		event.MediaID = p.Series.TvdbID
+		if len(p.Episodes) > 0 {
+			event.Title += fmt.Sprintf(" S%02dE%02d", p.Episodes[0].SeasonNumber, p.Episodes[0].EpisodeNumber)
+		}
+	case p.Movie != nil:
+		event.Title = p.Movie.Title
+		if p.Movie.Year > 0 {
+			event.Title += fmt.Sprintf(" (%d)", p.Movie.Year)
+		}
+		event.MediaID = p.Movie.TmdbID
+	}
+
+	switch event.Type {
+	case events.TypeGrab:
+		event.Message = fmt.Sprintf("%s grabbed a release for %s", sourceName(source), event.Title)
+	case events.TypeDownload:
+		event.Message = fmt.Sprintf("%s imported %s", sourceName(source), event.Title)
+	case events.TypeUpgrade:
+		event.Message = fmt.Sprintf("%s upgraded %s", sourceName(source), event.Title)
+	case events.TypeDelete:
+		event.Message = fmt.Sprintf("%s deleted %s", sourceName(source), event.Title)
+	case events.TypeHealthIssue:
+		event.Message = fmt.Sprintf("%s health issue (%s): %s", sourceName(source), p.Level, p.Message)
+	default:
+		event.Message = fmt.Sprintf("%s sent a %s event", sourceName(source), p.EventType)
+		if event.Title != "" {
+			event.Message += " for " + event.Title
+		}
+	}
+
+	return event
+}
+
+// eventType normalizes the arr event type onto the types mcparr reports.
+func eventType(p payload) string {
+	switch {
+	case p.EventType == "Download" && p.IsUpgrade:
+		return events.TypeUpgrade
+	case p.EventType == "Health":
+		return events.TypeHealthIssue
+	case strings.HasSuffix(p.EventType, "Delete"):
+		return events.TypeDelete
+	default:
+		return p.EventType
+	}
+}
+
+func sourceName(source string) string {
+	if source == "" {
+		return source
+	}
+	return strings.ToUpper(source[:1]) + source[1:]
+}
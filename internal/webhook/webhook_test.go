@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IdoKendo/mcparr/internal/events"
+)
+
+func TestWebhookRecordsEvent(t *testing.T) {
+	eventLog := events.NewLog(10)
+	mux := http.NewServeMux()
+	NewHandler("s3cret", eventLog).Routes(mux)
+
+	body := `{"eventType":"Download","isUpgrade":true,"movie":{"title":"Dune","year":2021,"tmdbId":438631}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/radarr", strings.NewReader(body))
+	req.SetBasicAuth("mcparr", "s3cret")
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", rec.Code)
+	}
+
+	recent := eventLog.Recent(0)
+	if len(recent) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(recent))
+	}
+
+	if recent[0].Type != events.TypeUpgrade {
+		t.Errorf("Expected event type '%s', got '%s'", events.TypeUpgrade, recent[0].Type)
+	}
+
+	if recent[0].MediaID != 438631 {
+		t.Errorf("Expected media ID 438631, got %d", recent[0].MediaID)
+	}
+}
+
+func TestWebhookRejectsInvalidSecret(t *testing.T) {
+	eventLog := events.NewLog(10)
+	mux := http.NewServeMux()
+	NewHandler("s3cret", eventLog).Routes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/sonarr?secret=wrong", strings.NewReader(`{"eventType":"Test"}`))
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", rec.Code)
+	}
+
+	if len(eventLog.Recent(0)) != 0 {
+		t.Error("Expected no events to be recorded")
+	}
+}
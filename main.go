@@ -1,82 +1,450 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"os/user"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/IdoKendo/mcparr/internal/approval"
+	"github.com/IdoKendo/mcparr/internal/audit"
 	"github.com/IdoKendo/mcparr/internal/config"
+	"github.com/IdoKendo/mcparr/internal/events"
+	"github.com/IdoKendo/mcparr/internal/metrics"
+	"github.com/IdoKendo/mcparr/internal/policy"
+	"github.com/IdoKendo/mcparr/internal/shutdown"
+	"github.com/IdoKendo/mcparr/internal/telemetry"
 	"github.com/IdoKendo/mcparr/internal/tools"
+	"github.com/IdoKendo/mcparr/internal/webhook"
 	"github.com/IdoKendo/mcparr/pkg/client"
 )
 
-func initLogger() error {
+const version = "1.0.0"
+
+// Exit statuses.
+const (
+	exitOK = 0
+	// exitError means the server failed.
+	exitError = 1
+	// exitAbandoned means tool calls were still running at the shutdown
+	// deadline and were cancelled; their outcome is unknown.
+	exitAbandoned = 2
+)
+
+func initLogger() (*os.File, error) {
 	usr, err := user.Current()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	logPath := filepath.Join(usr.HomeDir, ".cache", "mcparr", "history.log")
 	err = os.MkdirAll(filepath.Dir(logPath), 0755)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
+	// Stdout carries the JSON-RPC stream of the stdio transport, so logs go
+	// to stderr.
+	multiWriter := io.MultiWriter(os.Stderr, logFile)
 
 	log.SetOutput(multiWriter)
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.SetPrefix("[MCParr] ")
 
-	return nil
+	return logFile, nil
+}
+
+// eofReader calls onEOF once when the reader it wraps reaches its end.
+type eofReader struct {
+	io.Reader
+	onEOF func()
+	once  sync.Once
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.once.Do(r.onEOF)
+	}
+	return n, err
+}
+
+// notifyClients pushes an event as a log message to every connected MCP
+// session that allowed, which applies the session's content rules, lets see
+// it. The sessions are not in the context of a request, so clientID
+// identifies the client as it does for requests.
+func notifyClients(s *server.MCPServer, sessions *sync.Map, clientID string, allowed func(context.Context, events.Event) bool, e events.Event) {
+	level := mcp.LoggingLevelInfo
+	if e.Type == events.TypeHealthIssue {
+		level = mcp.LoggingLevelWarning
+	}
+
+	sessions.Range(func(_, value any) bool {
+		session := value.(server.ClientSession)
+		ctx := audit.WithUser(s.WithContext(context.Background(), session), clientID)
+		if !allowed(ctx, e) {
+			return true
+		}
+		err := s.SendNotificationToSpecificClient(session.SessionID(), "notifications/message", map[string]any{
+			"level":  level,
+			"logger": "mcparr",
+			"data":   e,
+		})
+		if err != nil && !errors.Is(err, server.ErrSessionNotInitialized) {
+			log.Printf("Error notifying session %s of event %d: %v", session.SessionID(), e.ID, err)
+		}
+		return true
+	})
+}
+
+// httpOptions converts the connection settings of an instance into client
+// options.
+func httpOptions(settings config.HTTPSettings) ([]client.Option, error) {
+	var opts []client.Option
+
+	if settings.CAFile != "" || settings.ClientCertFile != "" || settings.InsecureSkipVerify {
+		tlsConfig, err := client.NewTLSConfig(client.TLSOptions{
+			CAFile:             settings.CAFile,
+			CertFile:           settings.ClientCertFile,
+			KeyFile:            settings.ClientKeyFile,
+			InsecureSkipVerify: settings.InsecureSkipVerify,
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithTLSConfig(tlsConfig))
+	}
+
+	if settings.ProxyURL != "" {
+		proxyURL, err := url.Parse(settings.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		opts = append(opts, client.WithProxy(proxyURL))
+	}
+
+	if settings.BasicAuthUser != "" {
+		opts = append(opts, client.WithBasicAuth(settings.BasicAuthUser, settings.BasicAuthPassword))
+	}
+
+	if len(settings.Headers) > 0 {
+		opts = append(opts, client.WithHeaders(settings.Headers))
+	}
+
+	return opts, nil
+}
+
+// startHTTPServers serves the webhook receiver and the metrics endpoint.
+// They share a listener when configured with the same address.
+func startHTTPServers(cfg *config.Config, webhookHandler *webhook.Handler, metricsHandler http.Handler) []*http.Server {
+	muxes := make(map[string]*http.ServeMux)
+	muxFor := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		return muxes[addr]
+	}
+
+	if cfg.WebhookAddr() != "" {
+		webhookHandler.Routes(muxFor(cfg.WebhookAddr()))
+		log.Printf("Listening for webhooks on %s", cfg.WebhookAddr())
+	}
+	if cfg.MetricsAddr() != "" {
+		muxFor(cfg.MetricsAddr()).Handle("GET /metrics", metricsHandler)
+		log.Printf("Serving metrics on %s/metrics", cfg.MetricsAddr())
+	}
+
+	var servers []*http.Server
+	for addr, mux := range muxes {
+		srv := &http.Server{Addr: addr, Handler: mux}
+		servers = append(servers, srv)
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("HTTP server error on %s: %v", addr, err)
+			}
+		}()
+	}
+	return servers
 }
 
 func main() {
-	err := initLogger()
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(runAudit(os.Args[2:], os.Stdout))
+	}
+	os.Exit(run())
+}
+
+func run() int {
+	logFile, err := initLogger()
 	if err != nil {
 		log.Panic(err)
 	}
+	defer func() {
+		if err := logFile.Sync(); err != nil {
+			log.Printf("Error flushing log file: %v", err)
+		}
+		logFile.Close()
+	}()
 
 	log.Println("Starting MCParr server...")
 
-	s := server.NewMCPServer(
-		"MCP Arr",
-		"1.0.0",
-		server.WithToolCapabilities(false),
-		server.WithRecovery(),
-	)
-	log.Println("MCP server initialized")
-
 	cfg := config.New()
 	log.Println("Configuration loaded")
 
+	metricsRegistry := metrics.New()
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.TracingExporter(), version)
+	if err != nil {
+		log.Printf("Failed to set up tracing: %v", err)
+		return exitError
+	}
+	defer func() {
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelFlush()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Printf("Error flushing traces: %v", err)
+		}
+	}()
+
 	log.Println("Initializing API clients...")
-	sonarrClient := client.NewSonarrClient(cfg.SonarrURL(), cfg.SonarrAPIKey())
-	radarrClient := client.NewRadarrClient(cfg.RadarrURL(), cfg.RadarrAPIKey())
+	clientOpts := []client.Option{
+		client.WithObserver(metricsRegistry),
+		client.WithRetry(client.RetryPolicy{
+			MaxAttempts: cfg.RetryMaxAttempts(),
+			BaseDelay:   cfg.RetryBaseDelay(),
+			MaxDelay:    cfg.RetryMaxDelay(),
+		}),
+		client.WithCircuitBreaker(cfg.BreakerThreshold(), cfg.BreakerCooldown()),
+		client.WithCache(client.NewCacheTTLs(cfg.CacheLookupTTL(), cfg.CacheMetadataTTL())),
+		client.WithLimits(float64(cfg.RateLimit()), cfg.RateBurst(), cfg.MaxInFlight()),
+	}
+	sonarrHTTPOpts, err := httpOptions(cfg.SonarrHTTP())
+	if err != nil {
+		log.Printf("Invalid Sonarr connection settings: %v", err)
+		return exitError
+	}
+	radarrHTTPOpts, err := httpOptions(cfg.RadarrHTTP())
+	if err != nil {
+		log.Printf("Invalid Radarr connection settings: %v", err)
+		return exitError
+	}
+	sonarrClient := client.NewSonarrClient(cfg.SonarrURL(), cfg.SonarrAPIKey(), slices.Concat(clientOpts, sonarrHTTPOpts)...)
+	radarrClient := client.NewRadarrClient(cfg.RadarrURL(), cfg.RadarrAPIKey(), slices.Concat(clientOpts, radarrHTTPOpts)...)
+	metricsRegistry.RegisterInstance("Sonarr", sonarrClient)
+	metricsRegistry.RegisterInstance("Radarr", radarrClient)
 	log.Println("API clients initialized")
 
 	sonarrAdapter := tools.NewSonarrClientAdapter(sonarrClient)
 	radarrAdapter := tools.NewRadarrClientAdapter(radarrClient)
 	log.Println("Client adapters created")
 
+	completionIndex := tools.NewCompletionIndex(sonarrAdapter, radarrAdapter, cfg.CompletionRefresh())
+
+	hooks := &server.Hooks{}
+	subscriptions := tools.NewSubscriptions(hooks)
+	var sessions sync.Map
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		sessions.Store(session.SessionID(), session)
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		sessions.Delete(session.SessionID())
+	})
+
+	auditLog, err := audit.Open(cfg.AuditLogPath())
+	if err != nil {
+		log.Printf("Failed to open audit log: %v", err)
+		return exitError
+	}
+	defer func() {
+		if err := auditLog.Close(); err != nil {
+			log.Printf("Error closing audit log: %v", err)
+		}
+	}()
+
+	drainer := shutdown.NewDrainer()
+
+	s := server.NewMCPServer(
+		"MCP Arr",
+		version,
+		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(false),
+		server.WithLogging(),
+		server.WithHooks(hooks),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(completionIndex),
+		server.WithResourceCompletionProvider(completionIndex),
+		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(drainer.Middleware()),
+		server.WithToolHandlerMiddleware(audit.Middleware(auditLog, tools.AuditedTools, log.Default())),
+		server.WithToolHandlerMiddleware(telemetry.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(metricsRegistry.ToolMiddleware()),
+	)
+	subscriptions.Attach(s)
+	log.Println("MCP server initialized")
+
+	eventLog := events.NewLog(cfg.EventBufferSize())
+	eventLog.Subscribe(func(e events.Event) {
+		subscriptions.Notify(tools.ResourcesForEvent(e)...)
+		completionIndex.Invalidate()
+	})
+	httpServers := startHTTPServers(cfg, webhook.NewHandler(cfg.WebhookSecret(), eventLog), metricsRegistry.Handler())
+	defer func() {
+		stopCtx, cancelStop := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelStop()
+		for _, srv := range httpServers {
+			if err := srv.Shutdown(stopCtx); err != nil {
+				log.Printf("Error stopping HTTP server on %s: %v", srv.Addr, err)
+			}
+		}
+	}()
+
+	toolPolicy := &policy.Policy{}
+	if cfg.PolicyFile() != "" {
+		toolPolicy, err = policy.Load(cfg.PolicyFile())
+		if err != nil {
+			log.Printf("Failed to load tool policy: %v", err)
+			return exitError
+		}
+	}
+	toolPolicy.ReadOnly = toolPolicy.ReadOnly || cfg.ReadOnly()
+	toolPolicy.Allow = append(toolPolicy.Allow, cfg.AllowTools()...)
+	toolPolicy.Deny = append(toolPolicy.Deny, cfg.DenyTools()...)
+	if toolPolicy.Quota == nil && (cfg.QuotaMovies() > 0 || cfg.QuotaSeries() > 0) {
+		toolPolicy.Quota = &policy.Quota{Movies: cfg.QuotaMovies(), Series: cfg.QuotaSeries()}
+	}
+	completionIndex.SetContentRules(toolPolicy.ContentFor)
+	if toolPolicy.ReadOnly {
+		log.Println("Read-only mode: tools that change the library are disabled")
+	}
+
+	toolOpts := []tools.Option{
+		tools.WithEvents(eventLog),
+		tools.WithResourceNotifier(func(uris ...string) {
+			subscriptions.Notify(uris...)
+			completionIndex.Invalidate()
+		}),
+		tools.WithCompletionIndex(completionIndex),
+		tools.WithAuditTrail(auditLog),
+		tools.WithQuotas(toolPolicy.QuotaFor, cfg.QuotaPeriod()),
+		tools.WithContentRules(toolPolicy.ContentFor),
+		tools.WithTags(tools.TagSettings{
+			Requester: cfg.TagRequester(),
+			Movies:    cfg.MovieTags(),
+			Series:    cfg.SeriesTags(),
+		}),
+		tools.WithSpaceCheck(tools.SpaceSettings{
+			MinFree: int64(cfg.MinFreeSpaceGB()) << 30,
+			Refuse:  cfg.RefuseLowSpace(),
+		}),
+	}
+	if admins := cfg.AdminClients(); len(admins) > 0 {
+		approvals, err := approval.Open(cfg.ApprovalQueuePath())
+		if err != nil {
+			log.Printf("Failed to open approval queue: %v", err)
+			return exitError
+		}
+		toolOpts = append(toolOpts, tools.WithApprovals(approvals, admins))
+		log.Printf("Approval queue enabled, admins: %s", strings.Join(admins, ", "))
+	}
+
 	log.Println("Initializing MCP tools...")
-	mediaTools := tools.New(cfg, sonarrAdapter, radarrAdapter)
+	mediaTools := tools.New(
+		cfg,
+		sonarrAdapter,
+		radarrAdapter,
+		toolOpts...,
+	)
 	log.Println("MCP tools initialized")
 
-	s.AddTools(mediaTools.Tools()...)
+	eventLog.Subscribe(func(e events.Event) {
+		notifyClients(s, &sessions, cfg.ClientID(), mediaTools.EventAllowed, e)
+	})
+
+	s.AddTools(toolPolicy.Wrap(mediaTools.Tools(), tools.Mutates)...)
 	log.Println("Tools added to server")
 
+	s.AddResources(mediaTools.Resources()...)
+	s.AddResourceTemplates(mediaTools.ResourceTemplates()...)
+	log.Println("Resources added to server")
+
+	s.AddPrompts(mediaTools.Prompts()...)
+	log.Println("Prompts added to server")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(shutdown.ErrStopped)
+
+	// Stop reading requests, then give the running tool calls until the
+	// shutdown timeout to finish. Called on a signal or when stdin closes.
+	drain := sync.OnceValue(func() []string {
+		drainer.Stop()
+		cancel(shutdown.ErrStopped)
+		drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.ShutdownTimeout())
+		defer cancelDrain()
+		return drainer.Wait(drainCtx)
+	})
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %s, finishing in-flight requests (up to %s)...", sig, cfg.ShutdownTimeout())
+		go drain()
+
+		sig = <-signals
+		log.Printf("Received %s again, exiting immediately", sig)
+		os.Exit(exitAbandoned)
+	}()
+
 	log.Println("Starting server...")
-	if err := server.ServeStdio(s); err != nil {
-		log.Fatalf("Server error: %v", err)
+	stdioServer := server.NewStdioServer(s)
+	if clientID := cfg.ClientID(); clientID != "" {
+		log.Printf("Serving client %s", clientID)
+		stdioServer.SetContextFunc(func(ctx context.Context) context.Context {
+			return audit.WithUser(ctx, clientID)
+		})
+	} else {
+		log.Println("MCPARR_CLIENT_ID is not set, identifying the client by the name it gives itself")
+	}
+	stdioServer.SetErrorLogger(log.Default())
+	stdin := &eofReader{Reader: os.Stdin, onEOF: func() {
+		log.Printf("Stdin closed, finishing in-flight requests (up to %s)...", cfg.ShutdownTimeout())
+		go drain()
+	}}
+	err = stdioServer.Listen(ctx, stdin, os.Stdout)
+	abandoned := drain()
+
+	status := exitOK
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("Server error: %v", err)
+		status = exitError
 	}
+	if len(abandoned) > 0 {
+		log.Printf("Cancelled %d tool calls still running at the shutdown deadline, their outcome is unknown: %s",
+			len(abandoned), strings.Join(abandoned, ", "))
+		status = max(status, exitAbandoned)
+	}
+
+	log.Printf("MCParr stopped with status %d", status)
+	return status
 }
@@ -1,7 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/IdoKendo/mcparr/internal/audit"
+	"github.com/IdoKendo/mcparr/internal/events"
 )
 
 func TestMain(t *testing.T) {
@@ -9,3 +21,83 @@ func TestMain(t *testing.T) {
 	// We don't actually run the main function in tests
 	t.Log("Main package compiles successfully")
 }
+
+func TestRunAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog.Record(audit.Entry{User: "claude-desktop", Action: audit.ActionAdd, Title: "Arrival", Outcome: audit.OutcomeSuccess})
+	auditLog.Record(audit.Entry{User: "cursor", Action: audit.ActionDelete, Title: "Dune", Outcome: audit.OutcomeSuccess})
+	auditLog.Close()
+
+	var out bytes.Buffer
+	if status := runAudit([]string{"-file", path, "-user", "cursor"}, &out); status != exitOK {
+		t.Fatalf("Expected exit status %d, got %d: %s", exitOK, status, out.String())
+	}
+
+	if !strings.Contains(out.String(), "cursor delete Dune: success") {
+		t.Errorf("Expected output to contain the delete of Dune, got '%s'", out.String())
+	}
+	if strings.Contains(out.String(), "Arrival") {
+		t.Errorf("Expected output not to contain other users' actions, got '%s'", out.String())
+	}
+
+	if status := runAudit([]string{"-file", path, "-since", "yesterday"}, &out); status != exitError {
+		t.Errorf("Expected exit status %d for an invalid date, got %d", exitError, status)
+	}
+}
+
+func TestEOFReader(t *testing.T) {
+	calls := 0
+	r := &eofReader{Reader: strings.NewReader("{}\n"), onEOF: func() { calls++ }}
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "{}\n" {
+		t.Fatalf("Expected to read the input, got %q and %v", data, err)
+	}
+	r.Read(make([]byte, 1))
+
+	if calls != 1 {
+		t.Errorf("Expected onEOF to be called once, got %d", calls)
+	}
+}
+
+// testSession is an initialized MCP session that buffers its notifications.
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestNotifyClients(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0.0")
+	session := &testSession{id: "stdio", notifications: make(chan mcp.JSONRPCNotification, 2)}
+	if err := s.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	var sessions sync.Map
+	sessions.Store(session.id, session)
+
+	// The kids client may only see events about The Office.
+	allowed := func(ctx context.Context, e events.Event) bool {
+		return audit.User(ctx) != "kids-tablet" || e.Title == "The Office (US)"
+	}
+	notifyClients(s, &sessions, "kids-tablet", allowed, events.Event{Title: "Severance"})
+	notifyClients(s, &sessions, "kids-tablet", allowed, events.Event{Title: "The Office (US)"})
+
+	if len(session.notifications) != 1 {
+		t.Fatalf("Expected only the allowed event to be pushed, got %d notifications", len(session.notifications))
+	}
+	notification := <-session.notifications
+	if e := notification.Params.AdditionalFields["data"].(events.Event); e.Title != "The Office (US)" {
+		t.Errorf("Expected the event about The Office to be pushed, got %q", e.Title)
+	}
+}
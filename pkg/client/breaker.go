@@ -0,0 +1,120 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while an instance's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Circuit breaker states.
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops sending requests to an instance after repeated
+// failures, and lets a single trial request through once the cooldown has
+// passed.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow returns an error wrapping ErrCircuitOpen if requests to the instance
+// should fail fast.
+func (b *circuitBreaker) allow(name string) error {
+	if b == nil || b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		remaining := b.cooldown - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("%s is unreachable after %d failed requests, retrying in %s: %w",
+				name, b.failures, remaining.Round(time.Second), ErrCircuitOpen)
+		}
+		b.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		return fmt.Errorf("%s is unreachable, waiting for a trial request to finish: %w", name, ErrCircuitOpen)
+	default:
+		return nil
+	}
+}
+
+// record updates the breaker with the outcome of a request.
+func (b *circuitBreaker) record(success bool) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.failures = 0
+		b.state = circuitClosed
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abort releases a trial request that was cancelled before it completed, so
+// the next request can try again.
+func (b *circuitBreaker) abort() {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+	}
+}
+
+func (b *circuitBreaker) currentState() string {
+	if b == nil || b.threshold <= 0 {
+		return "closed"
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
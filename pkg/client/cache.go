@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheTTLs maps an endpoint, such as "movie/lookup", to how long its GET
+// responses are cached. Endpoints without an entry are never cached.
+type CacheTTLs map[string]time.Duration
+
+// NewCacheTTLs caches lookups, which are proxied to SkyHook and TMDb and are
+// slow, for lookupTTL, and tags and quality profiles, which rarely change,
+// for metadataTTL. A zero TTL disables caching for the group. Root folders
+// are not cached, as they carry the free space new items are placed by.
+func NewCacheTTLs(lookupTTL, metadataTTL time.Duration) CacheTTLs {
+	return CacheTTLs{
+		"series/lookup":  lookupTTL,
+		"movie/lookup":   lookupTTL,
+		"tag":            metadataTTL,
+		"qualityprofile": metadataTTL,
+	}
+}
+
+// DefaultCacheTTLs returns the cache TTLs used when none are configured.
+func DefaultCacheTTLs() CacheTTLs {
+	return NewCacheTTLs(5*time.Minute, time.Hour)
+}
+
+type cacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// responseCache caches GET responses per endpoint and collapses concurrent
+// identical requests into one.
+type responseCache struct {
+	ttls    CacheTTLs
+	group   singleflight.Group
+	now     func() time.Time
+	observe func(endpoint string, hit bool)
+
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	generation uint64
+}
+
+func newResponseCache(ttls CacheTTLs) *responseCache {
+	return &responseCache{
+		ttls:    ttls,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// get returns the cached response for endpoint and params, calling fetch on a
+// miss. Concurrent misses for the same request share a single fetch, which is
+// not cancelled when one of the callers gives up.
+func (c *responseCache) get(ctx context.Context, endpoint string, params map[string]string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if c == nil || c.ttls[endpoint] <= 0 {
+		return fetch(ctx)
+	}
+
+	ttl := c.ttls[endpoint]
+
+	key := cacheKey(endpoint, params)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	c.mu.Unlock()
+	hit := ok && c.now().Before(entry.expires)
+	if c.observe != nil {
+		c.observe(endpoint, hit)
+	}
+	if hit {
+		return entry.data, nil
+	}
+
+	ch := c.group.DoChan(key, func() (any, error) {
+		data, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		// Don't store a response that may predate an invalidation.
+		if c.generation == generation {
+			c.entries[key] = cacheEntry{data: data, expires: c.now().Add(ttl)}
+		}
+		c.mu.Unlock()
+
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.([]byte), nil
+	}
+}
+
+// invalidate drops every cached response.
+func (c *responseCache) invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+}
+
+func cacheKey(endpoint string, params map[string]string) string {
+	var key strings.Builder
+	key.WriteString(endpoint)
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		key.WriteString("&" + name + "=" + params[name])
+	}
+	return key.String()
+}
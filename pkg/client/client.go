@@ -3,123 +3,280 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates a span per HTTP request. It is a no-op unless the program
+// installs a global tracer provider.
+var tracer = otel.Tracer("github.com/IdoKendo/mcparr/pkg/client")
+
 // Client represents a generic API client for media services.
 type Client struct {
+	name       string
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	retry      RetryPolicy
+	breaker    *circuitBreaker
+	cache      *responseCache
+	limiter    *limiter
+	headers    http.Header
+	tlsConfig  *tls.Config
+	proxyURL   *url.URL
+	transport  http.RoundTripper
+	observer   Observer
 }
 
-// NewClient creates a new API client with the given base URL and API key.
-func NewClient(baseURL, apiKey string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+// Option configures optional Client settings.
+type Option func(*Client)
+
+// WithName sets the instance name used in error messages, e.g. "Radarr".
+func WithName(name string) Option {
+	return func(c *Client) {
+		c.name = name
 	}
 }
 
-// Get performs a GET request to the specified endpoint.
-func (c *Client) Get(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
-	url := fmt.Sprintf("%s/api/v3/%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
-	for key, value := range params {
-		url += fmt.Sprintf("&%s=%s", key, value)
+// WithRetry sets the retry policy for failed requests.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// WithCircuitBreaker opens the circuit after threshold consecutive failed
+// requests, failing fast until cooldown has passed. A threshold of zero
+// disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(threshold, cooldown)
 	}
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+// WithCache caches GET responses for the endpoints in ttls. A nil map
+// disables the cache.
+func WithCache(ttls CacheTTLs) Option {
+	return func(c *Client) {
+		c.cache = nil
+		if ttls != nil {
+			c.cache = newResponseCache(ttls)
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+// WithLimits allows requestsPerSecond requests per second to the instance,
+// with bursts of up to burst requests, and at most maxInFlight requests at a
+// time. Requests over the limits are queued until their context is done. A
+// zero rate or maxInFlight disables that limit.
+func WithLimits(requestsPerSecond float64, burst, maxInFlight int) Option {
+	return func(c *Client) {
+		c.limiter = newLimiter(requestsPerSecond, burst, maxInFlight)
 	}
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+// NewClient creates a new API client with the given base URL and API key.
+func NewClient(baseURL, apiKey string, opts ...Option) *Client {
+	c := &Client{
+		name:    baseURL,
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		retry:   DefaultRetryPolicy(),
+		breaker: newCircuitBreaker(5, 30*time.Second),
+		cache:   newResponseCache(DefaultCacheTTLs()),
+		limiter: newLimiter(10, 20, 4),
+		headers: make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.cache != nil {
+		c.cache.observe = c.observeCache
 	}
+	c.httpClient = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: c.buildTransport(),
+	}
+	return c
+}
 
-	return body, nil
+// LimiterStats returns how long requests have waited for the rate and
+// concurrency limits.
+func (c *Client) LimiterStats() LimiterStats {
+	return c.limiter.snapshot()
+}
+
+// Get performs a GET request to the specified endpoint.
+// Responses from cached endpoints may be served from the cache.
+func (c *Client) Get(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
+	return c.cache.get(ctx, endpoint, params, func(ctx context.Context) ([]byte, error) {
+		return c.do(ctx, http.MethodGet, endpoint, params, nil)
+	})
 }
 
 // Post performs a POST request to the specified endpoint with the given data.
 func (c *Client) Post(ctx context.Context, endpoint string, data any) ([]byte, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
-	}
+	defer c.cache.invalidate()
+	return c.do(ctx, http.MethodPost, endpoint, nil, data)
+}
 
-	url := fmt.Sprintf("%s/api/v3/%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
+// Put performs a PUT request to the specified endpoint with the given data.
+func (c *Client) Put(ctx context.Context, endpoint string, data any) ([]byte, error) {
+	defer c.cache.invalidate()
+	return c.do(ctx, http.MethodPut, endpoint, nil, data)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
+// Delete performs a delete request to the specified endpoint with the given
+// data. Like the original client, it is sent as a POST.
+func (c *Client) Delete(ctx context.Context, endpoint string, data any) ([]byte, error) {
+	defer c.cache.invalidate()
+	return c.do(ctx, http.MethodPost, endpoint, nil, data)
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+// do performs a request, retrying it according to the retry policy and
+// failing fast while the circuit breaker is open.
+func (c *Client) do(ctx context.Context, method, endpoint string, params map[string]string, data any) ([]byte, error) {
+	var jsonData []byte
+	if data != nil {
+		var err error
+		jsonData, err = json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal data: %w", err)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+	reqURL := fmt.Sprintf("%s/api/v3/%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
+	for key, value := range params {
+		reqURL += fmt.Sprintf("&%s=%s", key, value)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	if err := c.breaker.allow(c.name); err != nil {
+		return nil, err
 	}
 
-	return body, nil
+	for attempt := 1; ; attempt++ {
+		body, retryAfter, err := c.attempt(ctx, method, endpoint, reqURL, jsonData, attempt)
+		if err == nil {
+			c.breaker.record(true)
+			return body, nil
+		}
+
+		if ctx.Err() != nil || errors.Is(err, errLimitWait) {
+			c.breaker.abort()
+			return nil, err
+		}
+
+		// A server asking to wait longer than the policy allows is given up
+		// on rather than retried early or waited for.
+		if !c.retry.shouldRetry(method, err, attempt) || retryAfter > c.retry.MaxDelay {
+			c.breaker.record(!isUnavailable(err))
+			return nil, err
+		}
+
+		delay := max(c.retry.backoff(attempt), retryAfter)
+		select {
+		case <-ctx.Done():
+			c.breaker.abort()
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
 }
 
-// Post performs a DELETE request to the specified endpoint with the given data.
-func (c *Client) Delete(ctx context.Context, endpoint string, data any) ([]byte, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
+// attempt performs a single HTTP request and returns the response body, the
+// Retry-After delay requested by the server if any, and an error.
+func (c *Client) attempt(ctx context.Context, method, endpoint, reqURL string, jsonData []byte, attempt int) ([]byte, time.Duration, error) {
+	// The span deliberately leaves out the URL, which carries the API key.
+	ctx, span := tracer.Start(ctx, method+" "+normalizeEndpoint(endpoint),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("mcparr.instance", c.name),
+			attribute.String("mcparr.endpoint", normalizeEndpoint(endpoint)),
+			attribute.String("http.request.method", method),
+			attribute.Int("http.request.resend_count", attempt-1),
+		),
+	)
+	defer span.End()
+
+	var reqBody io.Reader
+	if jsonData != nil {
+		reqBody = bytes.NewReader(jsonData)
 	}
 
-	url := fmt.Sprintf("%s/api/v3/%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", c.redact(err))
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	if jsonData != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	release, err := c.limiter.acquire(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	defer release()
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		c.observeRequest(method, endpoint, 0, time.Since(start))
+		err = &requestError{err: fmt.Errorf("failed to execute request: %w", c.redact(err))}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
+	c.observeRequest(method, endpoint, resp.StatusCode, time.Since(start))
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		err := newAPIError(c.name, method, endpoint, resp, body)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		err = &requestError{err: fmt.Errorf("failed to read response body: %w", c.redact(err))}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
 	}
 
-	return body, nil
+	return body, 0, nil
+}
+
+// redact removes the API key from the URL carried by transport errors, so it
+// doesn't end up in logs, tool results or traces.
+func (c *Client) redact(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && c.apiKey != "" {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, c.apiKey, "REDACTED")
+	}
+	return err
 }
+
+// requestError is a transport level failure: the request may not have
+// reached the server, or the response was cut short.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+func (e *requestError) Unwrap() error { return e.err }
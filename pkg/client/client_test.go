@@ -2,9 +2,20 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -63,3 +74,736 @@ func TestClientGet(t *testing.T) {
 		t.Errorf("Expected response data to be '%s', got '%s'", expected, string(data))
 	}
 }
+
+func TestSonarrQueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/queue" {
+			t.Errorf("Expected request path to be '/api/v3/queue', got '%s'", r.URL.Path)
+		}
+
+		// Serve 101 records, one more than fit on a page.
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"page":2,"totalRecords":101,"records":[{"title":"Severance.S02E01","status":"downloading","size":100,"sizeleft":25}]}`))
+			return
+		}
+		records := strings.Repeat(`{"title":"Andor.S02E01","status":"queued"},`, 100)
+		w.Write([]byte(`{"page":1,"totalRecords":101,"records":[` + strings.TrimSuffix(records, ",") + `]}`))
+	}))
+	defer server.Close()
+
+	client := NewSonarrClient(server.URL, "test-api-key")
+
+	queue, err := client.Queue(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(queue) != 101 || queue[100].SizeLeft != 25 {
+		t.Errorf("Expected 101 queue items, the last with 25 bytes left, got %d", len(queue))
+	}
+}
+
+func TestParseExternalID(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   ExternalID
+		wantOK bool
+	}{
+		{"https://www.imdb.com/title/tt1160419/", ExternalID{Source: SourceIMDb, Value: "tt1160419"}, true},
+		{"tt1160419", ExternalID{Source: SourceIMDb, Value: "tt1160419"}, true},
+		{"tmdb:438631", ExternalID{Source: SourceTMDb, Value: "438631"}, true},
+		{"https://www.themoviedb.org/movie/438631-dune", ExternalID{Source: SourceTMDb, Value: "438631", Kind: "movie"}, true},
+		{"https://www.themoviedb.org/tv/95396-severance", ExternalID{Source: SourceTMDb, Value: "95396", Kind: "series"}, true},
+		{"https://thetvdb.com/?tab=series&id=371980", ExternalID{Source: SourceTVDb, Value: "371980", Kind: "series"}, true},
+		{"Dune", ExternalID{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseExternalID(tt.input)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("ParseExternalID(%q) = %+v, %v; want %+v, %v", tt.input, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLookupMovieByIMDbURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("term") != "imdb:tt1160419" {
+			t.Errorf("Expected term query parameter to be 'imdb:tt1160419', got '%s'", r.URL.Query().Get("term"))
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{"title":"Dune","year":2021,"tmdbId":438631}]`))
+	}))
+	defer server.Close()
+
+	client := NewRadarrClient(server.URL, "test-api-key")
+
+	movies, err := client.LookupMovie(context.Background(), "https://www.imdb.com/title/tt1160419/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(movies) != 1 || movies[0].ID != 438631 {
+		t.Errorf("Expected the exact match with ID 438631, got %v", movies)
+	}
+}
+
+func TestRequestMovieDownloadTags(t *testing.T) {
+	var added map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/tag":
+			w.Write([]byte(`{"id":7,"label":"requested-by-cursor"}`))
+		case "/api/v3/movie":
+			json.NewDecoder(r.Body).Decode(&added)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewRadarrClient(server.URL, "test-api-key")
+
+	tag, err := client.CreateTag(context.Background(), "requested-by-cursor")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tag.ID != 7 {
+		t.Errorf("Expected tag ID 7, got %d", tag.ID)
+	}
+
+	movie := Movie{ID: 329865, Title: "Arrival", Tags: []int{tag.ID}}
+	if err := client.RequestMovieDownload(context.Background(), movie, 6, "/movies"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tags, ok := added["tags"].([]any); !ok || len(tags) != 1 || tags[0] != float64(7) {
+		t.Errorf("Expected the movie to be added with tag 7, got %v", added["tags"])
+	}
+}
+
+func TestRadarrCollections(t *testing.T) {
+	var updated map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/collection/3":
+			w.Write([]byte(`{"id":3,"title":"Dune Collection","tmdbId":726871,"monitored":false,"qualityProfileId":1,` +
+				`"rootFolderPath":"/movies","searchOnAdd":true,"overview":"Dune saga","genres":["Science Fiction"],"tags":[4],"movies":[]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/collection":
+			if r.URL.Query().Get("tmdbId") != "726871" {
+				t.Errorf("Expected tmdbId 726871, got '%s'", r.URL.Query().Get("tmdbId"))
+			}
+			w.Write([]byte(`[{"id":3,"title":"Dune Collection","tmdbId":726871,"movies":[{"tmdbId":438631,"title":"Dune","year":2021,"status":"released","isExisting":true}]}]`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v3/collection/3":
+			json.NewDecoder(r.Body).Decode(&updated)
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewRadarrClient(server.URL, "test-api-key")
+
+	collections, err := client.Collections(context.Background(), 726871)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(collections) != 1 || len(collections[0].Movies) != 1 || !collections[0].Movies[0].IsExisting {
+		t.Fatalf("Expected the Dune collection with one owned movie, got %+v", collections)
+	}
+
+	collection := collections[0]
+	collection.Monitored = true
+	collection.QualityProfileID = 5
+	collection.RootFolderPath = "/mnt/movies"
+	if err := client.UpdateCollection(context.Background(), collection); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if updated["monitored"] != true || updated["qualityProfileId"] != float64(5) || updated["rootFolderPath"] != "/mnt/movies" {
+		t.Errorf("Expected the monitored collection to be sent, got %+v", updated)
+	}
+	if updated["overview"] != "Dune saga" || fmt.Sprint(updated["genres"]) != "[Science Fiction]" || fmt.Sprint(updated["tags"]) != "[4]" {
+		t.Errorf("Expected the fields Collection doesn't model to be kept, got %+v", updated)
+	}
+}
+
+func TestQualityProfiles(t *testing.T) {
+	var edited map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/qualityprofile":
+			w.Write([]byte(`[{"id":5,"name":"Ultra-HD","cutoff":1003,"items":[
+				{"quality":{"id":3,"name":"WEBDL-1080p","resolution":1080},"allowed":false},
+				{"id":1003,"name":"WEB 2160p","allowed":true,"items":[
+					{"quality":{"id":18,"name":"WEBDL-2160p","resolution":2160},"allowed":true},
+					{"quality":{"id":17,"name":"WEBRip-2160p","resolution":2160},"allowed":true}]},
+				{"quality":{"id":19,"name":"Bluray-2160p","resolution":2160},"allowed":true}]}]`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v3/movie/editor":
+			json.NewDecoder(r.Body).Decode(&edited)
+			w.Write([]byte(`[]`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewRadarrClient(server.URL, "test-api-key")
+
+	profiles, err := client.QualityProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(profiles) != 1 {
+		t.Fatalf("Expected 1 profile, got %d", len(profiles))
+	}
+	if allowed := strings.Join(profiles[0].AllowedQualities(), ", "); allowed != "WEBDL-2160p, WEBRip-2160p, Bluray-2160p" {
+		t.Errorf("Expected the 2160p qualities to be allowed, got '%s'", allowed)
+	}
+	if cutoff := profiles[0].CutoffName(); cutoff != "WEB 2160p" {
+		t.Errorf("Expected cutoff 'WEB 2160p', got '%s'", cutoff)
+	}
+
+	if err := client.EditMovies(context.Background(), []int{12, 15}, 5); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ids, ok := edited["movieIds"].([]any); !ok || len(ids) != 2 || edited["qualityProfileId"] != float64(5) {
+		t.Errorf("Expected movies 12 and 15 to be moved to profile 5, got %v", edited)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	fastRetry := WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{"GET retried after 503", http.MethodGet, []int{503, 200}, 2, false},
+		{"GET gives up after max attempts", http.MethodGet, []int{502, 502, 502, 502}, 3, true},
+		{"GET not retried on 404", http.MethodGet, []int{404, 200}, 1, true},
+		{"POST not retried on 500", http.MethodPost, []int{500, 200}, 1, true},
+		{"POST retried after 429", http.MethodPost, []int{429, 201}, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "test-api-key", fastRetry)
+			var err error
+			if tt.method == http.MethodGet {
+				_, err = client.Get(context.Background(), "test", nil)
+			} else {
+				_, err = client.Post(context.Background(), "test", map[string]string{"key": "value"})
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if attempts.Load() != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestClientRetryAfterOverMaxDelay(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}))
+	start := time.Now()
+	if _, err := client.Get(context.Background(), "test", nil); statusCode(err) != http.StatusTooManyRequests {
+		t.Errorf("Expected the 429 error, got %v", err)
+	}
+	if attempts.Load() != 1 || time.Since(start) > time.Second {
+		t.Errorf("Expected to give up at once, got %d attempts in %s", attempts.Load(), time.Since(start))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("2"); got != 2*time.Second {
+		t.Errorf("Expected 2s, got %s", got)
+	}
+	if got := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); got <= 0 || got > time.Minute {
+		t.Errorf("Expected a delay of up to a minute, got %s", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("Expected 0, got %s", got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewRadarrClient(server.URL, "test-api-key",
+		WithRetry(RetryPolicy{MaxAttempts: 1}),
+		WithCircuitBreaker(2, time.Minute),
+	)
+	now := time.Now()
+	client.client.breaker.now = func() time.Time { return now }
+
+	for range 2 {
+		if _, err := client.client.Get(context.Background(), "movie", nil); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected the circuit to be closed, got %v", err)
+		}
+	}
+
+	_, err := client.client.Get(context.Background(), "movie", nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "Radarr is unreachable") {
+		t.Errorf("Expected error to start with 'Radarr is unreachable', got '%s'", err)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("Expected 2 requests to reach the server, got %d", attempts.Load())
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := client.client.Get(context.Background(), "movie", nil); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a trial request after the cooldown, got %v", err)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 requests to reach the server, got %d", attempts.Load())
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+		check       func(error) bool
+	}{
+		{
+			name:        "validation",
+			status:      http.StatusBadRequest,
+			body:        `[{"propertyName":"TmdbId","errorMessage":"This movie has already been added","errorCode":"MovieExistsValidator"},{"propertyName":"RootFolderPath","errorMessage":"Root folder does not exist"}]`,
+			wantMessage: "Radarr rejected the request: TmdbId: This movie has already been added; RootFolderPath: Root folder does not exist",
+			check: func(err error) bool {
+				var validation *ValidationError
+				return errors.As(err, &validation) && len(validation.Fields) == 2 && validation.Fields[0].Code == "MovieExistsValidator"
+			},
+		},
+		{
+			name:        "unauthorized",
+			status:      http.StatusUnauthorized,
+			wantMessage: "Radarr returned non-OK HTTP status: 401 Unauthorized",
+			check: func(err error) bool {
+				var unauthorized *UnauthorizedError
+				return errors.As(err, &unauthorized)
+			},
+		},
+		{
+			name:        "not found",
+			status:      http.StatusNotFound,
+			body:        `{"message":"NotFound"}`,
+			wantMessage: "Radarr returned non-OK HTTP status: 404 Not Found: NotFound",
+			check: func(err error) bool {
+				var notFound *NotFoundError
+				return errors.As(err, &notFound)
+			},
+		},
+		{
+			name:        "conflict",
+			status:      http.StatusConflict,
+			wantMessage: "Radarr returned non-OK HTTP status: 409 Conflict",
+			check: func(err error) bool {
+				var conflict *ConflictError
+				return errors.As(err, &conflict)
+			},
+		},
+		{
+			name:        "server error",
+			status:      http.StatusInternalServerError,
+			body:        `{"message":"Database is locked","description":"stack trace"}`,
+			wantMessage: "Radarr returned non-OK HTTP status: 500 Internal Server Error: Database is locked",
+			check: func(err error) bool {
+				var serverErr *ServerError
+				return errors.As(err, &serverErr)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "test-api-key", WithName("Radarr"), WithRetry(RetryPolicy{MaxAttempts: 1}))
+			_, err := client.Post(context.Background(), "movie", map[string]string{"key": "value"})
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+
+			if err.Error() != tt.wantMessage {
+				t.Errorf("Expected error '%s', got '%s'", tt.wantMessage, err.Error())
+			}
+			if !tt.check(err) {
+				t.Errorf("Expected error to match its type, got %T", err)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected error to match *APIError, got %T", err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, apiErr.StatusCode)
+			}
+			if strings.Contains(apiErr.Endpoint, "apikey") {
+				t.Errorf("Expected endpoint not to contain the API key, got '%s'", apiErr.Endpoint)
+			}
+		})
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithCache(CacheTTLs{"movie/lookup": time.Minute}))
+	ctx := context.Background()
+	params := map[string]string{"term": "arrival"}
+
+	client.Get(ctx, "movie/lookup", params)
+	client.Get(ctx, "movie/lookup", params)
+	if requests.Load() != 1 {
+		t.Errorf("Expected the second lookup to be cached, got %d requests", requests.Load())
+	}
+
+	client.Get(ctx, "movie/lookup", map[string]string{"term": "dune"})
+	if requests.Load() != 2 {
+		t.Errorf("Expected a different term not to be cached, got %d requests", requests.Load())
+	}
+
+	client.Get(ctx, "queue", nil)
+	client.Get(ctx, "queue", nil)
+	if requests.Load() != 4 {
+		t.Errorf("Expected the queue not to be cached, got %d requests", requests.Load())
+	}
+
+	client.Post(ctx, "movie", map[string]string{"title": "Arrival"})
+	client.Get(ctx, "movie/lookup", params)
+	if requests.Load() != 6 {
+		t.Errorf("Expected the cache to be invalidated by a POST, got %d requests", requests.Load())
+	}
+
+	client.cache.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	client.Get(ctx, "movie/lookup", params)
+	if requests.Load() != 7 {
+		t.Errorf("Expected an expired entry to be fetched again, got %d requests", requests.Load())
+	}
+}
+
+func TestDefaultCacheTTLs(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithCache(DefaultCacheTTLs()))
+	ctx := context.Background()
+
+	client.Get(ctx, "tag", nil)
+	client.Get(ctx, "tag", nil)
+	if requests.Load() != 1 {
+		t.Errorf("Expected tags to be cached, got %d requests", requests.Load())
+	}
+
+	client.getRootFolders(ctx)
+	client.getRootFolders(ctx)
+	if requests.Load() != 3 {
+		t.Errorf("Expected root folders, which carry the free space, not to be cached, got %d requests", requests.Load())
+	}
+}
+
+func TestClientCacheSingleflight(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key")
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Get(context.Background(), "tag", nil); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}()
+	}
+
+	// Give the goroutines time to join the in-flight request.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if requests.Load() != 1 {
+		t.Errorf("Expected concurrent requests to share one fetch, got %d requests", requests.Load())
+	}
+}
+
+func TestClientLimits(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithLimits(0, 0, 2))
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Get(context.Background(), "queue", nil)
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight.Load() > 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", maxInFlight.Load())
+	}
+
+	stats := client.LimiterStats()
+	if stats.Requests != 6 {
+		t.Errorf("Expected 6 requests, got %d", stats.Requests)
+	}
+	if stats.Delayed == 0 || stats.MaxWait == 0 {
+		t.Errorf("Expected some requests to wait, got %+v", stats)
+	}
+}
+
+func TestLimiterKeepsTokenOfCancelledRequest(t *testing.T) {
+	// One request per 10s with a burst of two, one at a time.
+	l := newLimiter(0.1, 2, 1)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the first request to go through, got %v", err)
+	}
+
+	// Give up waiting for the only in-flight slot.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); !errors.Is(err, errLimitWait) {
+		t.Fatalf("Expected the second request to give up, got %v", err)
+	}
+	release()
+
+	// The second token of the burst is only left if the cancelled request
+	// did not use it up.
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); err != nil {
+		t.Errorf("Expected the third request to use the remaining token, got %v", err)
+	}
+}
+
+func TestClientRateLimitHonorsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key", WithLimits(0.1, 1, 0))
+
+	if _, err := client.Get(context.Background(), "queue", nil); err != nil {
+		t.Fatalf("Expected the first request to use the burst, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Get(ctx, "queue", nil)
+	if err == nil {
+		t.Fatal("Expected the queued request to fail when its context is done")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected the queued request to give up quickly, took %s", time.Since(start))
+	}
+}
+
+func TestClientTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	noRetry := WithRetry(RetryPolicy{MaxAttempts: 1})
+
+	if _, err := NewClient(server.URL, "test-api-key", noRetry).Get(context.Background(), "queue", nil); err == nil {
+		t.Error("Expected an untrusted certificate to be rejected")
+	}
+
+	tlsConfig, err := NewTLSConfig(TLSOptions{CAFile: caFile})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := NewClient(server.URL, "test-api-key", noRetry, WithTLSConfig(tlsConfig)).Get(context.Background(), "queue", nil); err != nil {
+		t.Errorf("Expected the CA bundle to be trusted, got %v", err)
+	}
+
+	if _, err := NewTLSConfig(TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("Expected a missing CA bundle to fail")
+	}
+}
+
+func TestClientAuthHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "admin" || password != "secret" {
+			t.Errorf("Expected basic auth admin:secret, got %s:%s", username, password)
+		}
+		if r.Header.Get("CF-Access-Client-Id") != "client-id" {
+			t.Errorf("Expected CF-Access-Client-Id header to be 'client-id', got '%s'", r.Header.Get("CF-Access-Client-Id"))
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-api-key",
+		WithBasicAuth("admin", "secret"),
+		WithHeaders(map[string]string{"CF-Access-Client-Id": "client-id"}),
+	)
+	if _, err := client.Get(context.Background(), "queue", nil); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestClientTransport(t *testing.T) {
+	var called bool
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(strings.NewReader(`[]`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	client := NewClient("http://sonarr.invalid", "test-api-key", WithTransport(transport))
+	if _, err := client.Get(context.Background(), "queue", nil); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !called {
+		t.Error("Expected the custom transport to be used")
+	}
+}
+
+type recordingObserver struct {
+	mu        sync.Mutex
+	endpoints []string
+	hits      []bool
+}
+
+func (o *recordingObserver) ObserveRequest(instance, method, endpoint string, statusCode int, duration time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.endpoints = append(o.endpoints, method+" "+endpoint)
+}
+
+func (o *recordingObserver) ObserveCache(instance, endpoint string, hit bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.hits = append(o.hits, hit)
+}
+
+func TestClientObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	client := NewClient(server.URL, "test-api-key", WithObserver(observer))
+	ctx := context.Background()
+
+	client.Get(ctx, "tag", nil)
+	client.Get(ctx, "tag", nil)
+	client.Delete(ctx, "movie/42", nil)
+
+	expected := []string{"GET tag", "POST movie/{id}"}
+	if strings.Join(observer.endpoints, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected requests %v, got %v", expected, observer.endpoints)
+	}
+	if len(observer.hits) != 2 || observer.hits[0] || !observer.hits[1] {
+		t.Errorf("Expected a cache miss then a hit, got %v", observer.hits)
+	}
+	if client.BreakerState() != "closed" {
+		t.Errorf("Expected breaker state 'closed', got '%s'", client.BreakerState())
+	}
+}
+
+func TestClientRedactsAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	client := NewClient(server.URL, "secret-api-key", WithRetry(RetryPolicy{MaxAttempts: 1}))
+	_, err := client.Get(context.Background(), "queue", nil)
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	if strings.Contains(err.Error(), "secret-api-key") {
+		t.Errorf("Expected the API key to be redacted, got '%s'", err)
+	}
+}
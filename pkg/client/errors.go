@@ -0,0 +1,158 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize limits how much of an error response body is read.
+const maxErrorBodySize = 64 << 10
+
+// APIError is a non-2xx response from Sonarr or Radarr. Every typed error
+// below wraps an APIError, so errors.As(err, &apiErr) matches all of them.
+type APIError struct {
+	// Instance is the name of the instance that answered, e.g. "Radarr".
+	Instance string
+	// Method and Endpoint describe the failed request, without the API key.
+	Method   string
+	Endpoint string
+	// StatusCode and Status are taken from the HTTP response.
+	StatusCode int
+	Status     string
+	// Message is the error message from the response body, if any.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s returned non-OK HTTP status: %s", e.Instance, e.Status)
+	}
+	return fmt.Sprintf("%s returned non-OK HTTP status: %s: %s", e.Instance, e.Status, e.Message)
+}
+
+// UnauthorizedError is returned when the API key is missing or rejected.
+type UnauthorizedError struct {
+	APIError
+}
+
+func (e *UnauthorizedError) Unwrap() error { return &e.APIError }
+
+// NotFoundError is returned when the requested item or endpoint does not
+// exist.
+type NotFoundError struct {
+	APIError
+}
+
+func (e *NotFoundError) Unwrap() error { return &e.APIError }
+
+// FieldError is a single validation failure reported by Sonarr or Radarr.
+type FieldError struct {
+	// Field is the property that failed validation, e.g. "RootFolderPath".
+	// It may be empty for errors that apply to the whole request.
+	Field string `json:"propertyName"`
+	// Message is the human readable reason, e.g. "This movie has already
+	// been added".
+	Message string `json:"errorMessage"`
+	// Code identifies the validator, e.g. "MovieExistsValidator".
+	Code string `json:"errorCode"`
+}
+
+// ValidationError is returned when Sonarr or Radarr rejects the request
+// body.
+type ValidationError struct {
+	APIError
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.APIError.Error()
+	}
+
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.String()
+	}
+	return fmt.Sprintf("%s rejected the request: %s", e.Instance, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Unwrap() error { return &e.APIError }
+
+// String returns the message prefixed with its field, if any.
+func (f FieldError) String() string {
+	if f.Field == "" {
+		return f.Message
+	}
+	return fmt.Sprintf("%s: %s", f.Field, f.Message)
+}
+
+// ConflictError is returned when the request conflicts with existing data.
+type ConflictError struct {
+	APIError
+}
+
+func (e *ConflictError) Unwrap() error { return &e.APIError }
+
+// ServerError is returned when Sonarr or Radarr fails with a 5xx status.
+type ServerError struct {
+	APIError
+}
+
+func (e *ServerError) Unwrap() error { return &e.APIError }
+
+// newAPIError builds the typed error matching a non-2xx response.
+func newAPIError(instance, method, endpoint string, resp *http.Response, body []byte) error {
+	apiErr := APIError{
+		Instance:   instance,
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+
+	// Validation failures come back as an array of field errors, everything
+	// else as an object with a message.
+	var fields []FieldError
+	if err := json.Unmarshal(body, &fields); err == nil && len(fields) > 0 {
+		messages := make([]string, len(fields))
+		for i, f := range fields {
+			messages[i] = f.String()
+		}
+		apiErr.Message = strings.Join(messages, "; ")
+	} else {
+		var payload struct {
+			Message     string `json:"message"`
+			Description string `json:"description"`
+		}
+		if err := json.Unmarshal(body, &payload); err == nil {
+			apiErr.Message = payload.Message
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return &UnauthorizedError{apiErr}
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{apiErr}
+	case resp.StatusCode == http.StatusConflict:
+		return &ConflictError{apiErr}
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity:
+		return &ValidationError{APIError: apiErr, Fields: fields}
+	case resp.StatusCode >= 500:
+		return &ServerError{apiErr}
+	default:
+		return &apiErr
+	}
+}
+
+// statusCode returns the HTTP status of an APIError, or zero.
+func statusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
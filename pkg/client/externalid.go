@@ -0,0 +1,77 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+)
+
+// External ID sources understood by the Sonarr and Radarr lookup endpoints.
+const (
+	SourceIMDb = "imdb"
+	SourceTMDb = "tmdb"
+	SourceTVDb = "tvdb"
+)
+
+// ExternalID is an IMDb, TMDb or TVDb identifier.
+type ExternalID struct {
+	// Source is one of SourceIMDb, SourceTMDb or SourceTVDb.
+	Source string
+	// Value is the identifier within the source, e.g. "tt1160419" or "438631".
+	Value string
+	// Kind is "movie" or "series" when the identifier came from a URL that
+	// says which one it is, and empty otherwise.
+	Kind string
+}
+
+var externalIDPatterns = []struct {
+	pattern *regexp.Regexp
+	source  string
+	kind    string
+}{
+	{regexp.MustCompile(`(?i)^(imdb|tmdb|tvdb):\s*(\w+)$`), "", ""},
+	{regexp.MustCompile(`(?i)imdb\.com/title/(tt\d+)`), SourceIMDb, ""},
+	{regexp.MustCompile(`(?i)^(tt\d{7,})$`), SourceIMDb, ""},
+	{regexp.MustCompile(`(?i)themoviedb\.org/movie/(\d+)`), SourceTMDb, "movie"},
+	{regexp.MustCompile(`(?i)themoviedb\.org/tv/(\d+)`), SourceTMDb, "series"},
+	{regexp.MustCompile(`(?i)thetvdb\.com/(?:dereferrer/)?series/(\d+)(?:[/?#]|$)`), SourceTVDb, "series"},
+	{regexp.MustCompile(`(?i)thetvdb\.com/.*[?&]id=(\d+)`), SourceTVDb, "series"},
+}
+
+// ParseExternalID recognizes IMDb, TMDb and TVDb IDs and URLs, such as
+// "https://www.imdb.com/title/tt1160419/", "tt1160419" or "tmdb:438631".
+func ParseExternalID(s string) (ExternalID, bool) {
+	s = strings.TrimSpace(s)
+
+	for _, p := range externalIDPatterns {
+		match := p.pattern.FindStringSubmatch(s)
+		if match == nil {
+			continue
+		}
+
+		if p.source == "" {
+			return ExternalID{Source: strings.ToLower(match[1]), Value: match[2]}, true
+		}
+		return ExternalID{Source: p.source, Value: match[1], Kind: p.kind}, true
+	}
+
+	return ExternalID{}, false
+}
+
+// Term returns the lookup term form of the ID, e.g. "imdb:tt1160419".
+func (id ExternalID) Term() string {
+	return id.Source + ":" + id.Value
+}
+
+// String returns a human readable form of the ID.
+func (id ExternalID) String() string {
+	switch id.Source {
+	case SourceIMDb:
+		return "IMDb " + id.Value
+	case SourceTMDb:
+		return "TMDb " + id.Value
+	case SourceTVDb:
+		return "TVDb " + id.Value
+	default:
+		return id.Term()
+	}
+}
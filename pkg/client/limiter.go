@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// errLimitWait is returned when a request gives up waiting for the limits.
+var errLimitWait = errors.New("gave up waiting for the request limits")
+
+// LimiterStats describes how long requests waited for the rate and
+// concurrency limits of an instance.
+type LimiterStats struct {
+	// Requests is the number of requests that went through the limiter.
+	Requests int64
+	// Delayed is the number of requests that had to wait.
+	Delayed int64
+	// TotalWait is the time spent waiting, summed over all requests.
+	TotalWait time.Duration
+	// MaxWait is the longest a single request waited.
+	MaxWait time.Duration
+	// InFlight is the number of requests currently being sent.
+	InFlight int
+}
+
+// limiter queues requests to an instance so that they respect a token bucket
+// rate limit and a maximum number of requests in flight.
+type limiter struct {
+	rate     *rate.Limiter
+	inFlight chan struct{}
+
+	mu    sync.Mutex
+	stats LimiterStats
+}
+
+// newLimiter allows requestsPerSecond requests per second with bursts of up
+// to burst requests, and at most maxInFlight concurrent requests. A zero
+// rate or maxInFlight disables that limit.
+func newLimiter(requestsPerSecond float64, burst, maxInFlight int) *limiter {
+	l := &limiter{}
+	if requestsPerSecond > 0 {
+		l.rate = rate.NewLimiter(rate.Limit(requestsPerSecond), max(burst, 1))
+	}
+	if maxInFlight > 0 {
+		l.inFlight = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// acquire waits until a request may be sent, or until ctx is done. The
+// returned function must be called once the request has finished.
+//
+// The in-flight slot is taken before the rate token, so a request that gives
+// up waiting for a slot does not use up a token.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	start := time.Now()
+
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", errLimitWait, ctx.Err())
+		}
+	}
+	release := func() {
+		if l.inFlight != nil {
+			<-l.inFlight
+		}
+	}
+
+	// Wait cancels its reservation, returning the token, if ctx is done.
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, fmt.Errorf("%w: %w", errLimitWait, err)
+		}
+	}
+
+	l.record(time.Since(start))
+
+	return release, nil
+}
+
+func (l *limiter) record(wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stats.Requests++
+	// Ignore the scheduling noise of requests that went straight through.
+	if wait > time.Millisecond {
+		l.stats.Delayed++
+		l.stats.TotalWait += wait
+		l.stats.MaxWait = max(l.stats.MaxWait, wait)
+	}
+}
+
+func (l *limiter) snapshot() LimiterStats {
+	if l == nil {
+		return LimiterStats{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := l.stats
+	stats.InFlight = len(l.inFlight)
+	return stats
+}
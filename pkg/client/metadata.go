@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// queuePageSize is how many queue items are fetched per request.
+const queuePageSize = 100
+
+// getTags returns the tags defined in the instance.
+func (c *Client) getTags(ctx context.Context) ([]Tag, error) {
+	data, err := c.Get(ctx, "tag", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	var tags []Tag
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to parse tag response: %w", err)
+	}
+
+	return tags, nil
+}
+
+// createTag creates a tag with the given label.
+func (c *Client) createTag(ctx context.Context, label string) (Tag, error) {
+	data, err := c.Post(ctx, "tag", map[string]any{"label": label})
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	var tag Tag
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return Tag{}, fmt.Errorf("failed to parse tag response: %w", err)
+	}
+
+	return tag, nil
+}
+
+// getQualityProfiles returns the quality profiles defined in the instance.
+func (c *Client) getQualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	data, err := c.Get(ctx, "qualityprofile", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quality profiles: %w", err)
+	}
+
+	var profiles []QualityProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse quality profile response: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// getDiskSpace returns the disks the instance sees and their free space.
+func (c *Client) getDiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	data, err := c.Get(ctx, "diskspace", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk space: %w", err)
+	}
+
+	var disks []DiskSpace
+	if err := json.Unmarshal(data, &disks); err != nil {
+		return nil, fmt.Errorf("failed to parse disk space response: %w", err)
+	}
+
+	return disks, nil
+}
+
+// getRootFolders returns the root folders configured in the instance.
+func (c *Client) getRootFolders(ctx context.Context) ([]RootFolder, error) {
+	data, err := c.Get(ctx, "rootfolder", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root folders: %w", err)
+	}
+
+	var folders []RootFolder
+	if err := json.Unmarshal(data, &folders); err != nil {
+		return nil, fmt.Errorf("failed to parse root folder response: %w", err)
+	}
+
+	return folders, nil
+}
+
+// getQueue returns the items in the download queue of the instance, fetching
+// it page by page until all records are read.
+func (c *Client) getQueue(ctx context.Context) ([]QueueItem, error) {
+	var items []QueueItem
+	for page := 1; ; page++ {
+		params := map[string]string{
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(queuePageSize),
+		}
+		data, err := c.Get(ctx, "queue", params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get queue: %w", err)
+		}
+
+		var result queuePage
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse queue response: %w", err)
+		}
+
+		items = append(items, result.Records...)
+		if len(result.Records) == 0 || len(items) >= result.TotalRecords {
+			return items, nil
+		}
+	}
+}
@@ -1,17 +1,186 @@
 package client
 
+import "time"
+
 // Series represents a TV series in Sonarr.
 type Series struct {
-	ID       int      `json:"tvdbId"`
-	Title    string   `json:"title"`
-	Overview string   `json:"overview,omitempty"`
-	Genres   []string `json:"genres,omitempty"`
+	ID            int      `json:"tvdbId"`
+	LibraryID     int      `json:"id,omitempty"`
+	Title         string   `json:"title"`
+	Year          int      `json:"year,omitempty"`
+	Status        string   `json:"status,omitempty"`
+	Overview      string   `json:"overview,omitempty"`
+	Genres        []string `json:"genres,omitempty"`
+	Certification string   `json:"certification,omitempty"`
+	ProfileID     int      `json:"qualityProfileId,omitempty"`
+	Path          string   `json:"path,omitempty"`
+	Tags          []int    `json:"tags,omitempty"`
+	Seasons       []Season `json:"seasons,omitempty"`
+}
+
+// Season is a season of a series.
+type Season struct {
+	SeasonNumber int  `json:"seasonNumber"`
+	Monitored    bool `json:"monitored"`
 }
 
 // Movie represents a movie in Radarr.
 type Movie struct {
-	ID       int      `json:"tmdbId"`
-	Title    string   `json:"title"`
-	Overview string   `json:"overview,omitempty"`
-	Genres   []string `json:"genres,omitempty"`
+	ID              int        `json:"tmdbId"`
+	LibraryID       int        `json:"id,omitempty"`
+	Title           string     `json:"title"`
+	Year            int        `json:"year,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	Overview        string     `json:"overview,omitempty"`
+	Genres          []string   `json:"genres,omitempty"`
+	Certification   string     `json:"certification,omitempty"`
+	HasFile         bool       `json:"hasFile,omitempty"`
+	InCinemas       *time.Time `json:"inCinemas,omitempty"`
+	DigitalRelease  *time.Time `json:"digitalRelease,omitempty"`
+	PhysicalRelease *time.Time `json:"physicalRelease,omitempty"`
+	ProfileID       int        `json:"qualityProfileId,omitempty"`
+	Path            string     `json:"path,omitempty"`
+	Tags            []int      `json:"tags,omitempty"`
+	// Collection is the TMDb collection the movie belongs to, if any.
+	Collection *MovieCollection `json:"collection,omitempty"`
+}
+
+// MovieCollection identifies the TMDb collection of a movie.
+type MovieCollection struct {
+	Title  string `json:"title"`
+	TmdbID int    `json:"tmdbId"`
+}
+
+// Collection represents a TMDb collection tracked by Radarr.
+type Collection struct {
+	ID                  int               `json:"id"`
+	Title               string            `json:"title"`
+	TmdbID              int               `json:"tmdbId"`
+	Monitored           bool              `json:"monitored"`
+	QualityProfileID    int               `json:"qualityProfileId"`
+	RootFolderPath      string            `json:"rootFolderPath"`
+	MinimumAvailability string            `json:"minimumAvailability,omitempty"`
+	SearchOnAdd         bool              `json:"searchOnAdd"`
+	Movies              []CollectionMovie `json:"movies"`
+}
+
+// CollectionMovie is a movie of a collection.
+type CollectionMovie struct {
+	TmdbID     int    `json:"tmdbId"`
+	Title      string `json:"title"`
+	Year       int    `json:"year"`
+	Status     string `json:"status"`
+	IsExisting bool   `json:"isExisting"`
+}
+
+// Episode represents an episode in the Sonarr calendar.
+type Episode struct {
+	Series        Series     `json:"series"`
+	SeasonNumber  int        `json:"seasonNumber"`
+	EpisodeNumber int        `json:"episodeNumber"`
+	Title         string     `json:"title"`
+	AirDateUTC    *time.Time `json:"airDateUtc,omitempty"`
+	HasFile       bool       `json:"hasFile"`
+}
+
+// QueueItem represents an item in the Sonarr or Radarr download queue.
+type QueueItem struct {
+	// SeriesID and MovieID are the library IDs of the series or movie being
+	// downloaded, set by Sonarr and Radarr respectively.
+	SeriesID                int        `json:"seriesId,omitempty"`
+	MovieID                 int        `json:"movieId,omitempty"`
+	Title                   string     `json:"title"`
+	Status                  string     `json:"status"`
+	TrackedDownloadState    string     `json:"trackedDownloadState,omitempty"`
+	Size                    float64    `json:"size"`
+	SizeLeft                float64    `json:"sizeleft"`
+	TimeLeft                string     `json:"timeleft,omitempty"`
+	EstimatedCompletionTime *time.Time `json:"estimatedCompletionTime,omitempty"`
+}
+
+// queuePage is a page of the paginated queue endpoint.
+type queuePage struct {
+	TotalRecords int         `json:"totalRecords"`
+	Records      []QueueItem `json:"records"`
+}
+
+// Tag represents a tag in Sonarr or Radarr.
+type Tag struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+}
+
+// QualityProfile represents a quality profile in Sonarr or Radarr.
+type QualityProfile struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Cutoff is the ID of the quality or group after which upgrades stop.
+	Cutoff int                  `json:"cutoff"`
+	Items  []QualityProfileItem `json:"items"`
+}
+
+// QualityProfileItem is a quality of a profile, or a group of qualities that
+// rank the same.
+type QualityProfileItem struct {
+	ID      int                  `json:"id,omitempty"`
+	Name    string               `json:"name,omitempty"`
+	Quality *Quality             `json:"quality,omitempty"`
+	Items   []QualityProfileItem `json:"items,omitempty"`
+	Allowed bool                 `json:"allowed"`
+}
+
+// Quality is a source and resolution, such as Bluray-1080p.
+type Quality struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Resolution int    `json:"resolution"`
+}
+
+// AllowedQualities returns the names of the qualities the profile allows,
+// from the lowest to the highest.
+func (p QualityProfile) AllowedQualities() []string {
+	var names []string
+	for _, item := range p.Items {
+		if !item.Allowed {
+			continue
+		}
+		if item.Quality != nil {
+			names = append(names, item.Quality.Name)
+		}
+		for _, sub := range item.Items {
+			if sub.Quality != nil {
+				names = append(names, sub.Quality.Name)
+			}
+		}
+	}
+	return names
+}
+
+// CutoffName returns the name of the cutoff quality or group of the profile.
+func (p QualityProfile) CutoffName() string {
+	for _, item := range p.Items {
+		switch {
+		case item.Quality != nil && item.Quality.ID == p.Cutoff:
+			return item.Quality.Name
+		case item.Quality == nil && item.ID == p.Cutoff:
+			return item.Name
+		}
+	}
+	return ""
+}
+
+// RootFolder represents a root folder in Sonarr or Radarr.
+type RootFolder struct {
+	ID         int    `json:"id"`
+	Path       string `json:"path"`
+	Accessible bool   `json:"accessible"`
+	FreeSpace  int64  `json:"freeSpace"`
+}
+
+// DiskSpace represents a disk seen by Sonarr or Radarr.
+type DiskSpace struct {
+	Path       string `json:"path"`
+	Label      string `json:"label"`
+	FreeSpace  int64  `json:"freeSpace"`
+	TotalSpace int64  `json:"totalSpace"`
 }
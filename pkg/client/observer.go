@@ -0,0 +1,58 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Observer is told about the requests a client makes, e.g. to export
+// metrics. Endpoints are normalized so that IDs don't create a new series
+// per item: "movie/42" is reported as "movie/{id}".
+type Observer interface {
+	// ObserveRequest is called after every HTTP request, including retries.
+	// statusCode is zero if no response was received.
+	ObserveRequest(instance, method, endpoint string, statusCode int, duration time.Duration)
+	// ObserveCache is called for every GET to a cached endpoint.
+	ObserveCache(instance, endpoint string, hit bool)
+}
+
+// WithObserver reports requests and cache lookups to observer.
+func WithObserver(observer Observer) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// Name returns the instance name, e.g. "Radarr".
+func (c *Client) Name() string {
+	return c.name
+}
+
+// BreakerState returns the state of the circuit breaker: "closed", "open" or
+// "half-open".
+func (c *Client) BreakerState() string {
+	return c.breaker.currentState()
+}
+
+func (c *Client) observeRequest(method, endpoint string, statusCode int, duration time.Duration) {
+	if c.observer != nil {
+		c.observer.ObserveRequest(c.name, method, normalizeEndpoint(endpoint), statusCode, duration)
+	}
+}
+
+func (c *Client) observeCache(endpoint string, hit bool) {
+	if c.observer != nil {
+		c.observer.ObserveCache(c.name, normalizeEndpoint(endpoint), hit)
+	}
+}
+
+func normalizeEndpoint(endpoint string) string {
+	segments := strings.Split(endpoint, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // RadarrClient is a client for interacting with the Radarr API.
@@ -14,14 +15,24 @@ type RadarrClient struct {
 }
 
 // NewRadarrClient creates a new Radarr API client.
-func NewRadarrClient(baseURL, apiKey string) *RadarrClient {
+func NewRadarrClient(baseURL, apiKey string, opts ...Option) *RadarrClient {
 	return &RadarrClient{
-		client: NewClient(baseURL, apiKey),
+		client: NewClient(baseURL, apiKey, append([]Option{WithName("Radarr")}, opts...)...),
 	}
 }
 
-// LookupMovie searches for movies by name.
+// LookupMovie searches for movies by name. IMDb and TMDb IDs and URLs are
+// looked up by ID and return the exact match.
 func (r *RadarrClient) LookupMovie(ctx context.Context, name string) ([]Movie, error) {
+	if id, ok := ParseExternalID(name); ok {
+		if id.Kind == "series" {
+			return nil, fmt.Errorf("%s is a series, not a movie", id)
+		}
+		if id.Source == SourceTVDb {
+			return nil, fmt.Errorf("movies can't be looked up by TVDb ID, use an IMDb or TMDb ID instead")
+		}
+		name = id.Term()
+	}
 	term := url.QueryEscape(name)
 
 	params := map[string]string{"term": term}
@@ -46,6 +57,9 @@ func (r *RadarrClient) RequestMovieDownload(ctx context.Context, movie Movie, qu
 		"qualityProfileId": qualityProfileID,
 		"rootFolderPath":   rootFolderPath,
 	}
+	if len(movie.Tags) > 0 {
+		data["tags"] = movie.Tags
+	}
 
 	_, err := r.client.Post(ctx, "movie", data)
 	if err != nil {
@@ -111,3 +125,167 @@ func (r *RadarrClient) RequestMovieDelete(ctx context.Context, movie Movie) erro
 
 	return nil
 }
+
+// ListMovies returns every movie in the Radarr library.
+func (r *RadarrClient) ListMovies(ctx context.Context) ([]Movie, error) {
+	data, err := r.client.Get(ctx, "movie", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list movies: %w", err)
+	}
+
+	var movies []Movie
+	if err := json.Unmarshal(data, &movies); err != nil {
+		return nil, fmt.Errorf("failed to parse movie response: %w", err)
+	}
+
+	return movies, nil
+}
+
+// GetMovie returns the library movie with the given TMDb ID.
+func (r *RadarrClient) GetMovie(ctx context.Context, tmdbID int) (Movie, error) {
+	params := map[string]string{"tmdbId": fmt.Sprint(tmdbID)}
+	data, err := r.client.Get(ctx, "movie", params)
+	if err != nil {
+		return Movie{}, fmt.Errorf("failed to get movie: %w", err)
+	}
+
+	var movies []Movie
+	if err := json.Unmarshal(data, &movies); err != nil {
+		return Movie{}, fmt.Errorf("failed to parse movie response: %w", err)
+	}
+
+	if len(movies) == 0 {
+		return Movie{}, fmt.Errorf("movie with TMDb ID %d is not in the library", tmdbID)
+	}
+
+	return movies[0], nil
+}
+
+// Calendar returns the movies releasing between start and end.
+func (r *RadarrClient) Calendar(ctx context.Context, start, end time.Time) ([]Movie, error) {
+	params := map[string]string{
+		"start": start.UTC().Format(time.RFC3339),
+		"end":   end.UTC().Format(time.RFC3339),
+	}
+	data, err := r.client.Get(ctx, "calendar", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	var movies []Movie
+	if err := json.Unmarshal(data, &movies); err != nil {
+		return nil, fmt.Errorf("failed to parse calendar response: %w", err)
+	}
+
+	return movies, nil
+}
+
+// Queue returns the items currently in the Radarr download queue.
+func (r *RadarrClient) Queue(ctx context.Context) ([]QueueItem, error) {
+	return r.client.getQueue(ctx)
+}
+
+// EditMovies moves the movies with the given library IDs to a quality profile
+// through the movie editor.
+func (r *RadarrClient) EditMovies(ctx context.Context, libraryIDs []int, qualityProfileID int) error {
+	data := map[string]any{
+		"movieIds":         libraryIDs,
+		"qualityProfileId": qualityProfileID,
+	}
+
+	_, err := r.client.Put(ctx, "movie/editor", data)
+	if err != nil {
+		return fmt.Errorf("failed to edit movies: %w", err)
+	}
+
+	return nil
+}
+
+// Collections returns the collections Radarr tracks, or only the one with
+// the given TMDb ID if tmdbID is not zero.
+func (r *RadarrClient) Collections(ctx context.Context, tmdbID int) ([]Collection, error) {
+	var params map[string]string
+	if tmdbID != 0 {
+		params = map[string]string{"tmdbId": fmt.Sprint(tmdbID)}
+	}
+	data, err := r.client.Get(ctx, "collection", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+
+	var collections []Collection
+	if err := json.Unmarshal(data, &collections); err != nil {
+		return nil, fmt.Errorf("failed to parse collection response: %w", err)
+	}
+
+	return collections, nil
+}
+
+// UpdateCollection saves the settings of a collection: whether it is
+// monitored so Radarr adds its new movies automatically, and the quality
+// profile, root folder, minimum availability and search on add of the movies
+// it adds. The collection is read back from Radarr and only these settings
+// are changed, so the fields Collection doesn't model, such as its tags,
+// overview and genres, are kept.
+func (r *RadarrClient) UpdateCollection(ctx context.Context, collection Collection) error {
+	endpoint := fmt.Sprintf("collection/%d", collection.ID)
+	data, err := r.client.Get(ctx, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse collection response: %w", err)
+	}
+
+	raw["monitored"] = collection.Monitored
+	raw["qualityProfileId"] = collection.QualityProfileID
+	raw["rootFolderPath"] = collection.RootFolderPath
+	raw["searchOnAdd"] = collection.SearchOnAdd
+	if collection.MinimumAvailability != "" {
+		raw["minimumAvailability"] = collection.MinimumAvailability
+	}
+
+	if _, err := r.client.Put(ctx, endpoint, raw); err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+
+	return nil
+}
+
+// Tags returns the tags defined in Radarr.
+func (r *RadarrClient) Tags(ctx context.Context) ([]Tag, error) {
+	return r.client.getTags(ctx)
+}
+
+// CreateTag creates a tag in Radarr.
+func (r *RadarrClient) CreateTag(ctx context.Context, label string) (Tag, error) {
+	return r.client.createTag(ctx, label)
+}
+
+// QualityProfiles returns the quality profiles defined in Radarr.
+func (r *RadarrClient) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	return r.client.getQualityProfiles(ctx)
+}
+
+// RootFolders returns the root folders configured in Radarr.
+func (r *RadarrClient) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	return r.client.getRootFolders(ctx)
+}
+
+// DiskSpace returns the disks Radarr sees and their free space.
+func (r *RadarrClient) DiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	return r.client.getDiskSpace(ctx)
+}
+
+// LimiterStats returns how long requests to Radarr have waited for the rate
+// and concurrency limits.
+func (r *RadarrClient) LimiterStats() LimiterStats {
+	return r.client.LimiterStats()
+}
+
+// BreakerState returns the state of the Radarr circuit breaker.
+func (r *RadarrClient) BreakerState() string {
+	return r.client.BreakerState()
+}
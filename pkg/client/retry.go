@@ -0,0 +1,102 @@
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles on every
+	// following retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts. A request the server asks
+	// to retry after longer than MaxDelay is not retried.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+}
+
+// shouldRetry reports whether a request that failed with err on the given
+// attempt should be tried again. Rate limited requests are always retried,
+// since the server did not process them; transport failures and server
+// errors are only retried for idempotent methods.
+func (p RetryPolicy) shouldRetry(method string, err error, attempt int) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if statusCode(err) == http.StatusTooManyRequests {
+		return true
+	}
+
+	return isIdempotent(method) && isUnavailable(err)
+}
+
+// backoff returns a jittered delay before the retry following attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Equal jitter: half the delay is fixed, the other half is random.
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// isUnavailable reports whether err means the instance could not serve the
+// request: a transport failure or a 5xx response.
+func isUnavailable(err error) bool {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return true
+	}
+
+	return statusCode(err) >= 500
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date, returning zero if it is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
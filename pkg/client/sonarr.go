@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // SonarrClient is a client for interacting with the Sonarr API.
@@ -14,14 +15,21 @@ type SonarrClient struct {
 }
 
 // NewSonarrClient creates a new Sonarr API client.
-func NewSonarrClient(baseURL, apiKey string) *SonarrClient {
+func NewSonarrClient(baseURL, apiKey string, opts ...Option) *SonarrClient {
 	return &SonarrClient{
-		client: NewClient(baseURL, apiKey),
+		client: NewClient(baseURL, apiKey, append([]Option{WithName("Sonarr")}, opts...)...),
 	}
 }
 
-// LookupSeries searches for series by name.
+// LookupSeries searches for series by name. IMDb, TMDb and TVDb IDs and URLs
+// are looked up by ID and return the exact match.
 func (s *SonarrClient) LookupSeries(ctx context.Context, name string) ([]Series, error) {
+	if id, ok := ParseExternalID(name); ok {
+		if id.Kind == "movie" {
+			return nil, fmt.Errorf("%s is a movie, not a series", id)
+		}
+		name = id.Term()
+	}
 	term := url.QueryEscape(name)
 
 	params := map[string]string{"term": term}
@@ -46,6 +54,9 @@ func (s *SonarrClient) RequestSeriesDownload(ctx context.Context, series Series,
 		"qualityProfileId": qualityProfileID,
 		"rootFolderPath":   rootFolderPath,
 	}
+	if len(series.Tags) > 0 {
+		data["tags"] = series.Tags
+	}
 
 	_, err := s.client.Post(ctx, "series", data)
 	if err != nil {
@@ -55,6 +66,22 @@ func (s *SonarrClient) RequestSeriesDownload(ctx context.Context, series Series,
 	return nil
 }
 
+// EditSeries moves the series with the given library IDs to a quality
+// profile through the series editor.
+func (s *SonarrClient) EditSeries(ctx context.Context, libraryIDs []int, qualityProfileID int) error {
+	data := map[string]any{
+		"seriesIds":        libraryIDs,
+		"qualityProfileId": qualityProfileID,
+	}
+
+	_, err := s.client.Put(ctx, "series/editor", data)
+	if err != nil {
+		return fmt.Errorf("failed to edit series: %w", err)
+	}
+
+	return nil
+}
+
 // SearchSeriesByGenre searches for series by genre.
 func (s *SonarrClient) SearchSeriesByGenre(ctx context.Context, genre string, similarTo string, limit int) ([]Series, error) {
 	var allSeries []Series
@@ -109,3 +136,99 @@ func (s *SonarrClient) RequestSeriesDelete(ctx context.Context, series Series) e
 	}
 	return nil
 }
+
+// ListSeries returns every series in the Sonarr library.
+func (s *SonarrClient) ListSeries(ctx context.Context) ([]Series, error) {
+	data, err := s.client.Get(ctx, "series", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+
+	var series []Series
+	if err := json.Unmarshal(data, &series); err != nil {
+		return nil, fmt.Errorf("failed to parse series response: %w", err)
+	}
+
+	return series, nil
+}
+
+// GetSeries returns the library series with the given TVDb ID.
+func (s *SonarrClient) GetSeries(ctx context.Context, tvdbID int) (Series, error) {
+	params := map[string]string{"tvdbId": fmt.Sprint(tvdbID)}
+	data, err := s.client.Get(ctx, "series", params)
+	if err != nil {
+		return Series{}, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	var series []Series
+	if err := json.Unmarshal(data, &series); err != nil {
+		return Series{}, fmt.Errorf("failed to parse series response: %w", err)
+	}
+
+	if len(series) == 0 {
+		return Series{}, fmt.Errorf("series with TVDb ID %d is not in the library", tvdbID)
+	}
+
+	return series[0], nil
+}
+
+// Calendar returns the episodes airing between start and end.
+func (s *SonarrClient) Calendar(ctx context.Context, start, end time.Time) ([]Episode, error) {
+	params := map[string]string{
+		"start":         start.UTC().Format(time.RFC3339),
+		"end":           end.UTC().Format(time.RFC3339),
+		"includeSeries": "true",
+	}
+	data, err := s.client.Get(ctx, "calendar", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	var episodes []Episode
+	if err := json.Unmarshal(data, &episodes); err != nil {
+		return nil, fmt.Errorf("failed to parse calendar response: %w", err)
+	}
+
+	return episodes, nil
+}
+
+// Queue returns the items currently in the Sonarr download queue.
+func (s *SonarrClient) Queue(ctx context.Context) ([]QueueItem, error) {
+	return s.client.getQueue(ctx)
+}
+
+// Tags returns the tags defined in Sonarr.
+func (s *SonarrClient) Tags(ctx context.Context) ([]Tag, error) {
+	return s.client.getTags(ctx)
+}
+
+// CreateTag creates a tag in Sonarr.
+func (s *SonarrClient) CreateTag(ctx context.Context, label string) (Tag, error) {
+	return s.client.createTag(ctx, label)
+}
+
+// QualityProfiles returns the quality profiles defined in Sonarr.
+func (s *SonarrClient) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	return s.client.getQualityProfiles(ctx)
+}
+
+// RootFolders returns the root folders configured in Sonarr.
+func (s *SonarrClient) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	return s.client.getRootFolders(ctx)
+}
+
+// DiskSpace returns the disks Sonarr sees and their free space.
+func (s *SonarrClient) DiskSpace(ctx context.Context) ([]DiskSpace, error) {
+	return s.client.getDiskSpace(ctx)
+}
+
+// LimiterStats returns how long requests to Sonarr have waited for the rate
+// and concurrency limits.
+func (s *SonarrClient) LimiterStats() LimiterStats {
+	return s.client.LimiterStats()
+}
+
+// BreakerState returns the state of the Sonarr circuit breaker.
+func (s *SonarrClient) BreakerState() string {
+	return s.client.BreakerState()
+}
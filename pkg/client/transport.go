@@ -0,0 +1,127 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TLSOptions configures how the client verifies and authenticates to an
+// instance served over HTTPS.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of certificate authorities trusted in addition
+	// to the system ones, for instances using a private CA.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key, for
+	// instances behind a proxy that requires mutual TLS.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables certificate verification altogether.
+	InsecureSkipVerify bool
+}
+
+// NewTLSConfig builds a tls.Config from opts, loading the CA bundle and the
+// client certificate from disk.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to the instance.
+// It is ignored when a custom transport is set with WithTransport.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config
+	}
+}
+
+// WithProxy sends requests through the given forward proxy instead of the
+// one from the HTTP_PROXY and HTTPS_PROXY environment variables. It is
+// ignored when a custom transport is set with WithTransport.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		c.proxyURL = proxyURL
+	}
+}
+
+// WithBasicAuth adds HTTP basic auth to every request, for instances behind
+// an authenticating reverse proxy.
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) {
+		c.headers.Set("Authorization", "Basic "+basicAuth(username, password))
+	}
+}
+
+// WithHeaders adds static headers to every request, such as Cloudflare
+// Access service token headers.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		for name, value := range headers {
+			c.headers.Set(name, value)
+		}
+	}
+}
+
+// WithTransport sets the http.RoundTripper used to send requests, for
+// programs embedding the client that need full control over connections.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// buildTransport returns the custom transport if one is set, or a copy of
+// the default transport with the TLS and proxy settings applied.
+func (c *Client) buildTransport() http.RoundTripper {
+	if c.transport != nil {
+		return c.transport
+	}
+	if c.tlsConfig == nil && c.proxyURL == nil {
+		return http.DefaultTransport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig
+	}
+	if c.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(c.proxyURL)
+	}
+	return transport
+}
+
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}